@@ -0,0 +1,38 @@
+// Package agekey turns the X25519 device keys into filippo.io/age
+// recipients and identities. Blobs are written and read by the age library
+// itself, so they decrypt offline with the `age` tool.
+package agekey
+
+import (
+	"crypto/ecdh"
+	"errors"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+)
+
+const identityHRP = "AGE-SECRET-KEY-"
+
+// Recipient returns the age recipient for a 32-byte X25519 public key.
+func Recipient(pub []byte) (*age.X25519Recipient, error) {
+	key, err := ecdh.X25519().NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	s, err := plugin.EncodeX25519Recipient(key)
+	if err != nil {
+		return nil, err
+	}
+	return age.ParseX25519Recipient(s)
+}
+
+// Identity returns the age identity for an X25519 private key. The age
+// library can generate and parse identities but not wrap an existing key, so
+// the key is encoded as "AGE-SECRET-KEY-1..." and parsed back.
+func Identity(priv *ecdh.PrivateKey) (*age.X25519Identity, error) {
+	if priv == nil || priv.Curve() != ecdh.X25519() {
+		return nil, errors.New("not an X25519 key")
+	}
+	return age.ParseX25519Identity(strings.ToUpper(bech32Encode(identityHRP, priv.Bytes())))
+}
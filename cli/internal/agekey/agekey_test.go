@@ -0,0 +1,172 @@
+package agekey
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+// testdata/example.age and example_keys.txt are the test vector shipped with
+// filippo.io/age; exampleScalar is the key in example_keys.txt.
+const (
+	exampleScalar    = "3d65b16d80bc73ae3c30f9f457bea48f153233c38bf3c29710cb661bbd44e313"
+	exampleIdentity  = "AGE-SECRET-KEY-184JMZMVQH3E6U0PSL869004Y3U2NYV7R30EU99CSEDNPH02YUVFSZW44VU"
+	exampleRecipient = "age1cy0su9fwf3gf9mw868g5yut09p6nytfmmnktexz2ya5uqg9vl9sss4euqm"
+	examplePlaintext = "Black lives matter."
+)
+
+func exampleKey(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	scalar, err := hex.DecodeString(exampleScalar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(scalar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func TestExampleVector(t *testing.T) {
+	priv := exampleKey(t)
+	id, err := Identity(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.String() != exampleIdentity {
+		t.Fatalf("identity = %s, want %s", id, exampleIdentity)
+	}
+	keys, err := os.ReadFile(filepath.Join("testdata", "example_keys.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(keys), exampleIdentity) {
+		t.Fatal("testdata/example_keys.txt does not hold the example identity")
+	}
+
+	r, err := Recipient(priv.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != exampleRecipient || id.Recipient().String() != exampleRecipient {
+		t.Fatalf("recipient = %s, want %s", r, exampleRecipient)
+	}
+
+	f, err := os.Open(filepath.Join("testdata", "example.age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	plain, err := age.Decrypt(f, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != examplePlaintext {
+		t.Fatalf("plaintext = %q, want %q", got, examplePlaintext)
+	}
+}
+
+func TestIdentityRoundTrip(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := Identity(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := Recipient(priv.PublicKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.Recipient().String() != r.String() {
+			t.Fatalf("identity recipient %s != %s", id.Recipient(), r)
+		}
+	}
+	if _, err := Recipient(make([]byte, 31)); err == nil {
+		t.Fatal("accepted a short public key")
+	}
+}
+
+// TestAgeCLIRoundTrip checks blobs against the reference `age` tool, when it
+// is installed.
+func TestAgeCLIRoundTrip(t *testing.T) {
+	bin, err := exec.LookPath("age")
+	if err != nil {
+		t.Skip("age not installed")
+	}
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := Identity(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Recipient(priv.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(keyFile, []byte(id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	plain := bytes.Repeat([]byte("API_KEY=secret\n"), 10000)
+
+	// Ours to age.
+	var sealed bytes.Buffer
+	w, err := age.Encrypt(&sealed, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(bin, "-d", "-i", keyFile)
+	cmd.Stdin = &sealed
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("age -d: %v", err)
+	}
+	if !bytes.Equal(out, plain) {
+		t.Fatal("age -d output differs")
+	}
+
+	// age to ours.
+	cmd = exec.Command(bin, "-r", r.String())
+	cmd.Stdin = bytes.NewReader(plain)
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("age -r: %v", err)
+	}
+	dec, err := age.Decrypt(bytes.NewReader(out), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatal("decrypted output differs")
+	}
+}
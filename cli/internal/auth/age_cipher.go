@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/mgeovany/sentra/cli/internal/agekey"
+)
+
+func sealAge(plain []byte, recipients []Recipient) ([]byte, error) {
+	seen := map[string]struct{}{}
+	var ageRecipients []age.Recipient
+	for _, r := range recipients {
+		key := strings.TrimSpace(r.PublicKey)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		pub, err := base64.RawURLEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key for machine %s: %w", r.MachineID, err)
+		}
+		recipient, err := agekey.Recipient(pub)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key for machine %s: %w", r.MachineID, err)
+		}
+		ageRecipients = append(ageRecipients, recipient)
+	}
+	if len(ageRecipients) == 0 {
+		return nil, errors.New("no recipients to encrypt to")
+	}
+
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, ageRecipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func openAge(raw []byte) ([]byte, error) {
+	ids, err := ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]age.Identity, 0, len(ids))
+	for _, priv := range ids {
+		id, err := agekey.Identity(priv)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, id)
+	}
+	r, err := age.Decrypt(bytes.NewReader(raw), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("this machine is not a recipient of this blob (run: sentra link)")
+		}
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// ageIdentities is this machine's X25519 key followed by linked ones.
+func ageIdentities() ([]*ecdh.PrivateKey, error) {
+	priv, err := GetOrCreateDeviceEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	ids := []*ecdh.PrivateKey{priv}
+	linked := linkedIdentities()
+	machineIDs := make([]string, 0, len(linked))
+	for id := range linked {
+		machineIDs = append(machineIDs, id)
+	}
+	sort.Strings(machineIDs)
+	for _, id := range machineIDs {
+		ids = append(ids, linked[id]...)
+	}
+	return ids, nil
+}
+
+// AgeRecipient returns this machine's encryption key as an age recipient.
+func AgeRecipient() (string, error) {
+	priv, err := GetOrCreateDeviceEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	r, err := agekey.Recipient(priv.PublicKey().Bytes())
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+// AgeIdentityFile renders every identity this machine holds in the age
+// identity file format, for `age -d -i <file>`.
+func AgeIdentityFile() ([]byte, error) {
+	ids, err := ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for i, id := range ids {
+		identity, err := agekey.Identity(id)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("# created: " + time.Now().UTC().Format(time.RFC3339) + "\n")
+		b.WriteString("# public key: " + identity.Recipient().String() + "\n")
+		b.WriteString(identity.String() + "\n")
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/zalando/go-keyring"
+)
+
+func TestSealOpenAge(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+
+	self, err := GetOrCreateDeviceEncryptionPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub := base64.RawURLEncoding.EncodeToString(other.PublicKey().Bytes())
+	plain := []byte("API_KEY=secret\n")
+
+	sealed, err := sealAge(plain, []Recipient{{MachineID: "self", PublicKey: self}, {MachineID: "other", PublicKey: otherPub}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := openAge(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("opened %q, want %q", got, plain)
+	}
+
+	// The identity file sentra key identity writes opens it with plain age.
+	file, err := AgeIdentityFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := age.ParseIdentities(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(sealed), ids...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("age.Decrypt = %q, %v", got, err)
+	}
+
+	notForUs, err := sealAge(plain, []Recipient{{MachineID: "other", PublicKey: otherPub}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openAge(notForUs); err == nil || !strings.Contains(err.Error(), "not a recipient") {
+		t.Fatalf("openAge for another machine: %v", err)
+	}
+}
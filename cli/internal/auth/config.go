@@ -18,9 +18,11 @@ type Config struct {
 	// StorageMode controls whether the CLI uploads encrypted blobs to user-managed
 	// object storage (BYOS) or sends blobs inline for the hosted provider.
 	// Values: "hosted" (default) | "byos".
-	StorageMode string    `json:"storage_mode,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	Version     int       `json:"version"`
+	StorageMode string `json:"storage_mode,omitempty"`
+	// RecoveryExportedAt is set by `sentra key export`; doctor warns while it is nil.
+	RecoveryExportedAt *time.Time `json:"recovery_exported_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	Version            int        `json:"version"`
 }
 
 func configPath() (string, error) {
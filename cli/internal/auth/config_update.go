@@ -1,5 +1,7 @@
 package auth
 
+import "time"
+
 // SetUserID stores the authenticated user id in config.
 // It keeps existing machine_id stable.
 func SetUserID(userID string) error {
@@ -28,3 +30,14 @@ func SetUserID(userID string) error {
 	cfg.UserID = userID
 	return SaveConfig(cfg)
 }
+
+// MarkRecoveryExported records that a recovery kit was exported.
+func MarkRecoveryExported(at time.Time) error {
+	cfg, err := EnsureConfig()
+	if err != nil {
+		return err
+	}
+	at = at.UTC()
+	cfg.RecoveryExportedAt = &at
+	return SaveConfig(cfg)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	deviceEncKeyringUser = "device-x25519"
+)
+
+// GetOrCreateDeviceEncryptionKey returns this machine's X25519 key. Content
+// keys for pushed blobs are wrapped to the public half of it (see envelope.go).
+func GetOrCreateDeviceEncryptionKey() (*ecdh.PrivateKey, error) {
+	// 1) Prefer OS keyring.
+	if k, err := getOrCreateDeviceEncKeyKeyring(); err == nil {
+		return k, nil
+	}
+
+	// 2) Fallback: local key file (0600), same trade-off as session.key.
+	return getOrCreateDeviceEncKeyFile()
+}
+
+func GetOrCreateDeviceEncryptionPublicKey() (string, error) {
+	priv, err := GetOrCreateDeviceEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(priv.PublicKey().Bytes()), nil
+}
+
+func parseDeviceEncKey(s string) (*ecdh.PrivateKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != 32 {
+		return nil, errors.New("invalid device encryption key length")
+	}
+	return ecdh.X25519().NewPrivateKey(raw)
+}
+
+func getOrCreateDeviceEncKeyKeyring() (*ecdh.PrivateKey, error) {
+	v, err := keyring.Get(keyringService, deviceEncKeyringUser)
+	if err == nil && strings.TrimSpace(v) != "" {
+		return parseDeviceEncKey(v)
+	}
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return nil, err
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.RawURLEncoding.EncodeToString(priv.Bytes())
+	if err := keyring.Set(keyringService, deviceEncKeyringUser, enc); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+func deviceEncKeyPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sentra", "device.x25519"), nil
+}
+
+func getOrCreateDeviceEncKeyFile() (*ecdh.PrivateKey, error) {
+	p, err := deviceEncKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	if b, err := os.ReadFile(p); err == nil {
+		k, parseErr := parseDeviceEncKey(string(b))
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid device.x25519: %w", parseErr)
+		}
+		return k, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.RawURLEncoding.EncodeToString(priv.Bytes())
+	if err := os.WriteFile(p, []byte(enc), 0o600); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
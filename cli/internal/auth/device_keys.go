@@ -13,6 +13,7 @@ import (
 const (
 	deviceKeyringUser = "device-ed25519"
 	deviceSigVersion  = "v2"
+	encKeySigContext  = "sentra-enc-pub-key-v1"
 )
 
 func canonicalDeviceMessage(machineID, timestamp, nonce, method, path string, body []byte) []byte {
@@ -86,3 +87,37 @@ func SignDeviceRequest(machineID, timestamp, nonce, method, path string, body []
 	sig := ed25519.Sign(priv, msg)
 	return base64.RawURLEncoding.EncodeToString(sig), nil
 }
+
+// encPubKeyMessage is what a machine signs with its device key to vouch for
+// its encryption key. Must match server canonicalization.
+func encPubKeyMessage(machineID, encPubKey string) []byte {
+	return []byte(encKeySigContext + "\n" + strings.TrimSpace(machineID) + "\n" + strings.TrimSpace(encPubKey))
+}
+
+// SignEncPubKey signs this machine's encryption key with its device key, so
+// machines that pinned the device key can tell the server didn't swap it.
+func SignEncPubKey(machineID, encPubKey string) (string, error) {
+	priv, err := GetOrCreateDevicePrivateKey()
+	if err != nil {
+		return "", err
+	}
+	sig := ed25519.Sign(priv, encPubKeyMessage(machineID, encPubKey))
+	return base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyEncPubKey checks that encPubKey was signed for machineID by the
+// device key devicePubKey.
+func VerifyEncPubKey(devicePubKey, machineID, encPubKey, sig string) error {
+	pub, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(devicePubKey))
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid device public key")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(sig))
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return errors.New("missing or invalid encryption key signature")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), encPubKeyMessage(machineID, encPubKey), raw) {
+		return errors.New("encryption key signature does not match the device key")
+	}
+	return nil
+}
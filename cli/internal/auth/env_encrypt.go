@@ -1,89 +1,135 @@
 package auth
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"io"
 	"strings"
 )
 
 const (
+	// envEncCipher is the original format: AES-256-GCM under the per-installation
+	// key. It can only be decrypted on the machine that pushed it; still readable,
+	// no longer written.
 	envEncCipher = "ed25519+aes-256-gcm-v1"
+	// CipherLegacy is envEncCipher as the server reports it.
+	CipherLegacy = envEncCipher
+
+	// CipherSentraV2 wraps a per-file AES key to every machine (see
+	// envelope.go), with the project root, file path and plaintext sha256 as
+	// associated data.
+	CipherSentraV2 = envEnvelopeCipherV2
+	// CipherAgeV1 is the standard age v1 format with X25519 recipients; blobs
+	// can be decrypted offline with `age -d -i` and `sentra key identity`.
+	// age has no associated data, so these blobs are not bound to their path.
+	CipherAgeV1 = "age-v1"
+
+	DefaultEnvCipher = CipherSentraV2
 )
 
-// EncryptEnvBlob encrypts plaintext bytes using a per-installation symmetric key.
-// This is client-side encryption for "opaque blob" storage.
-func EncryptEnvBlob(plain []byte) (cipherName string, b64Ciphertext string, size int, err error) {
-	key, err := getOrCreateSessionKey()
-	if err != nil {
-		return "", "", 0, err
+// ErrBlobBinding means a sentra-v2 blob failed to authenticate against the
+// file it was served for. GCM cannot tell why: it was encrypted for another
+// project, path or content, or it was damaged.
+var ErrBlobBinding = errors.New("blob does not match this file (served under a different path or content, or corrupted)")
+
+// WritableEnvCiphers lists the ciphers EncryptEnvBlob can produce.
+var WritableEnvCiphers = []string{CipherSentraV2, CipherAgeV1}
+
+func IsWritableEnvCipher(name string) bool {
+	for _, c := range WritableEnvCiphers {
+		if c == name {
+			return true
+		}
 	}
-	if len(key) != 32 {
-		return "", "", 0, fmt.Errorf("invalid encryption key length")
+	return false
+}
+
+// IsBoundEnvCipher reports whether blobs of this cipher are bound to the
+// project, path and content they were pushed for. The others decrypt
+// whatever file the server serves them as.
+func IsBoundEnvCipher(name string) bool {
+	return strings.TrimSpace(name) == CipherSentraV2
+}
+
+// EncryptEnvBlob encrypts plaintext bytes client-side for "opaque blob" storage.
+// Every recipient machine can decrypt the result. An empty cipherName selects
+// DefaultEnvCipher. binding.SHA256 is computed from plain.
+func EncryptEnvBlob(cipherName string, plain []byte, recipients []Recipient, binding BlobBinding) (string, string, int, error) {
+	cipherName = strings.TrimSpace(cipherName)
+	if cipherName == "" {
+		cipherName = DefaultEnvCipher
 	}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", "", 0, err
+	var raw []byte
+	var err error
+	switch cipherName {
+	case CipherSentraV2:
+		binding.SHA256 = SHA256Hex(plain)
+		raw, err = sealEnvelope(envelopeV2, plain, recipients, binding.aad())
+	case CipherAgeV1:
+		raw, err = sealAge(plain, recipients)
+	default:
+		return "", "", 0, fmt.Errorf("unsupported cipher: %s", cipherName)
 	}
-	gcm, err := cipher.NewGCM(block)
 	if err != nil {
 		return "", "", 0, err
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", "", 0, err
-	}
-
-	ct := gcm.Seal(nil, nonce, plain, nil)
-	out := append(nonce, ct...)
 	// Return plaintext size, not ciphertext size, since the push schema validates
-	// against plaintext size limits (1 MiB). The ciphertext includes nonce + GCM tag overhead.
-	return envEncCipher, base64.RawURLEncoding.EncodeToString(out), len(plain), nil
+	// against plaintext size limits (1 MiB). The ciphertext includes envelope overhead.
+	return cipherName, base64.RawURLEncoding.EncodeToString(raw), len(plain), nil
 }
 
-func DecryptEnvBlob(cipherName string, b64Ciphertext string) ([]byte, error) {
+// DecryptEnvBlob decrypts a blob served for binding (the path and sha256 the
+// server claims). For sentra-v2 a mismatch returns ErrBlobBinding. The cipher
+// name comes from the server too: callers that expect a bound cipher must
+// check it first (see IsBoundEnvCipher).
+func DecryptEnvBlob(cipherName string, b64Ciphertext string, binding BlobBinding) ([]byte, error) {
 	cipherName = strings.TrimSpace(cipherName)
 	if cipherName == "" {
 		cipherName = envEncCipher
 	}
-	if cipherName != envEncCipher {
-		return nil, fmt.Errorf("unsupported cipher: %s", cipherName)
-	}
-
-	key, err := getOrCreateSessionKey()
-	if err != nil {
-		return nil, err
-	}
-	if len(key) != 32 {
-		return nil, fmt.Errorf("invalid encryption key length")
-	}
 
 	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(b64Ciphertext))
 	if err != nil {
 		return nil, err
 	}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
+	switch cipherName {
+	case envEncCipher:
+		return decryptLegacyEnvBlob(raw)
+	case envEnvelopeCipherV2:
+		return openEnvelope(envelopeV2, raw, binding.aad())
+	case CipherAgeV1:
+		return openAge(raw)
+	default:
+		return nil, fmt.Errorf("unsupported cipher: %s", cipherName)
 	}
-	gcm, err := cipher.NewGCM(block)
+}
+
+// decryptLegacyEnvBlob tries this machine's key first, then keys received via `sentra link`.
+func decryptLegacyEnvBlob(raw []byte) ([]byte, error) {
+	key, err := getOrCreateSessionKey()
 	if err != nil {
 		return nil, err
 	}
-	if len(raw) < gcm.NonceSize() {
-		return nil, fmt.Errorf("invalid ciphertext")
-	}
-	nonce := raw[:gcm.NonceSize()]
-	ct := raw[gcm.NonceSize():]
-	pt, err := gcm.Open(nil, nonce, ct, nil)
-	if err != nil {
-		return nil, err
+	keys := append([][]byte{key}, linkedSessionKeys()...)
+
+	for _, k := range keys {
+		if len(k) != 32 {
+			continue
+		}
+		gcm, err := newAESGCM(k)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) < gcm.NonceSize() {
+			return nil, fmt.Errorf("invalid ciphertext")
+		}
+		nonce := raw[:gcm.NonceSize()]
+		ct := raw[gcm.NonceSize():]
+		if pt, err := gcm.Open(nil, nonce, ct, nil); err == nil {
+			return pt, nil
+		}
 	}
-	return pt, nil
+	return nil, errors.New("cannot decrypt blob: it was pushed by another machine (run: sentra link)")
 }
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	envEnvelopeCipherV2 = "sentra-v2"
+	envelopeV2          = 2
+
+	// envelopeWrapInfo keeps the label of the first envelope format, which
+	// sentra-v2 blobs were sealed with.
+	envelopeWrapInfo = "sentra-v1 key wrap"
+)
+
+// Recipient is a registered machine that must be able to decrypt a pushed blob.
+type Recipient struct {
+	MachineID string
+	// PublicKey is the machine's X25519 public key (base64url, no padding).
+	PublicKey string
+}
+
+// envelope is the "sentra-v2" blob format: the file is encrypted once with a
+// random content key, and that key is wrapped separately to every recipient.
+// A BlobBinding is authenticated as associated data.
+type envelope struct {
+	V          int                 `json:"v"`
+	Recipients []envelopeRecipient `json:"recipients"`
+	Nonce      string              `json:"nonce"`
+	Data       string              `json:"data"`
+}
+
+type envelopeRecipient struct {
+	MachineID string `json:"machine_id"`
+	x25519Box
+}
+
+// x25519Box is a one-shot ECIES box: an ephemeral X25519 key agreement with
+// the recipient, HKDF-SHA256 to derive an AES-256-GCM key, and the ciphertext.
+type x25519Box struct {
+	EphemeralPub string `json:"epk"`
+	Nonce        string `json:"nonce"`
+	Data         string `json:"data"`
+}
+
+// BlobBinding is where a blob belongs. sentra-v2 authenticates it, so a blob
+// served for a different project, path or content fails to decrypt.
+type BlobBinding struct {
+	Root string
+	// Path is the full file path including the root (e.g. "api/.env").
+	Path   string
+	SHA256 string
+}
+
+func (b BlobBinding) aad() []byte {
+	// NUL can't occur in any of the fields, so the encoding is unambiguous.
+	return []byte(envEnvelopeCipherV2 + "\x00" + strings.TrimSpace(b.Root) + "\x00" + strings.TrimSpace(b.Path) + "\x00" + strings.ToLower(strings.TrimSpace(b.SHA256)))
+}
+
+func sealEnvelope(version int, plain []byte, recipients []Recipient, aad []byte) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients to encrypt to")
+	}
+
+	contentKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, contentKey); err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	env := envelope{
+		V:     version,
+		Nonce: base64.RawURLEncoding.EncodeToString(nonce),
+		Data:  base64.RawURLEncoding.EncodeToString(gcm.Seal(nil, nonce, plain, aad)),
+	}
+
+	seen := map[string]struct{}{}
+	for _, r := range recipients {
+		machineID := strings.TrimSpace(r.MachineID)
+		if machineID == "" {
+			continue
+		}
+		if _, ok := seen[machineID]; ok {
+			continue
+		}
+		seen[machineID] = struct{}{}
+
+		pub, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(r.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key for machine %s: %w", machineID, err)
+		}
+		box, err := sealToX25519(pub, contentKey, envelopeWrapInfo, []byte(machineID))
+		if err != nil {
+			return nil, fmt.Errorf("cannot wrap key for machine %s: %w", machineID, err)
+		}
+		env.Recipients = append(env.Recipients, envelopeRecipient{MachineID: machineID, x25519Box: box})
+	}
+	if len(env.Recipients) == 0 {
+		return nil, errors.New("no recipients to encrypt to")
+	}
+
+	return json.Marshal(env)
+}
+
+func openEnvelope(version int, raw []byte, aad []byte) ([]byte, error) {
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if env.V != version {
+		return nil, fmt.Errorf("unsupported envelope version: %d", env.V)
+	}
+
+	contentKey, err := unwrapContentKey(env.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := base64.RawURLEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := base64.RawURLEncoding.DecodeString(env.Data)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid envelope nonce size")
+	}
+	pt, err := gcm.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return nil, ErrBlobBinding
+	}
+	return pt, nil
+}
+
+// unwrapContentKey finds the recipient entry this machine can open. Its own
+// entry is tried first; the others are tried too in case the machine id changed.
+// Keys received via `sentra link` open entries for the machines they came from.
+func unwrapContentKey(recipients []envelopeRecipient) ([]byte, error) {
+	priv, err := GetOrCreateDeviceEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ownID := ""
+	if cfg, ok, err := LoadConfig(); err == nil && ok {
+		ownID = strings.TrimSpace(cfg.MachineID)
+	}
+
+	ordered := make([]envelopeRecipient, 0, len(recipients))
+	for _, r := range recipients {
+		if r.MachineID == ownID {
+			ordered = append([]envelopeRecipient{r}, ordered...)
+			continue
+		}
+		ordered = append(ordered, r)
+	}
+
+	for _, r := range ordered {
+		key, err := openX25519Box(priv, r.x25519Box, envelopeWrapInfo, []byte(r.MachineID))
+		if err == nil && len(key) == 32 {
+			return key, nil
+		}
+	}
+
+	linked := linkedIdentities()
+	for _, r := range recipients {
+		for _, lp := range linked[r.MachineID] {
+			key, err := openX25519Box(lp, r.x25519Box, envelopeWrapInfo, []byte(r.MachineID))
+			if err == nil && len(key) == 32 {
+				return key, nil
+			}
+		}
+	}
+	return nil, errors.New("this machine is not a recipient of this blob (run: sentra link)")
+}
+
+func sealToX25519(recipientPub []byte, plain []byte, info string, aad []byte) (x25519Box, error) {
+	pub, err := ecdh.X25519().NewPublicKey(recipientPub)
+	if err != nil {
+		return x25519Box{}, err
+	}
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return x25519Box{}, err
+	}
+	shared, err := eph.ECDH(pub)
+	if err != nil {
+		return x25519Box{}, err
+	}
+
+	ephPub := eph.PublicKey().Bytes()
+	gcm, err := x25519BoxAEAD(shared, ephPub, recipientPub, info)
+	if err != nil {
+		return x25519Box{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return x25519Box{}, err
+	}
+
+	return x25519Box{
+		EphemeralPub: base64.RawURLEncoding.EncodeToString(ephPub),
+		Nonce:        base64.RawURLEncoding.EncodeToString(nonce),
+		Data:         base64.RawURLEncoding.EncodeToString(gcm.Seal(nil, nonce, plain, aad)),
+	}, nil
+}
+
+func openX25519Box(priv *ecdh.PrivateKey, box x25519Box, info string, aad []byte) ([]byte, error) {
+	ephPub, err := base64.RawURLEncoding.DecodeString(box.EphemeralPub)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(box.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := base64.RawURLEncoding.DecodeString(box.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	eph, err := ecdh.X25519().NewPublicKey(ephPub)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := priv.ECDH(eph)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := x25519BoxAEAD(shared, ephPub, priv.PublicKey().Bytes(), info)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid box nonce size")
+	}
+	return gcm.Open(nil, nonce, ct, aad)
+}
+
+func x25519BoxAEAD(shared, ephPub, recipientPub []byte, info string) (cipher.AEAD, error) {
+	salt := make([]byte, 0, len(ephPub)+len(recipientPub))
+	salt = append(salt, ephPub...)
+	salt = append(salt, recipientPub...)
+	key, err := hkdf.Key(sha256.New, shared, salt, info, 32)
+	if err != nil {
+		return nil, err
+	}
+	return newAESGCM(key)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const linkBundleInfo = "sentra-v1 link bundle"
+
+// LinkCode is a short fingerprint of a machine's device and encryption keys.
+// Both sides of `sentra link` (and `sentra machines trust`) print it so the
+// user can check the server didn't swap either key.
+func LinkCode(devicePubKey, encPubKey string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(devicePubKey) + "\n" + strings.TrimSpace(encPubKey)))
+	h := strings.ToUpper(hex.EncodeToString(sum[:6]))
+	return h[0:4] + "-" + h[4:8] + "-" + h[8:12]
+}
+
+// SealLinkBundle packs this machine's key material (its own keys plus any it
+// was linked with) and seals it to the new machine's encryption key.
+func SealLinkBundle(recipientMachineID string, recipientEncPubKey string) (string, error) {
+	recipientMachineID = strings.TrimSpace(recipientMachineID)
+	if recipientMachineID == "" {
+		return "", errors.New("missing machine id")
+	}
+	pub, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(recipientEncPubKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	bundle, err := linkBundleKeys(recipientMachineID)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := json.Marshal(bundle)
+	if err != nil {
+		return "", err
+	}
+	box, err := sealToX25519(pub, plain, linkBundleInfo, []byte(recipientMachineID))
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(box)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// linkBundleKeys is what SealLinkBundle hands to machine recipientMachineID:
+// this machine's key material, and the machines it trusts plus itself, so the
+// new machine's pushes reach the machines that can already read the account.
+func linkBundleKeys(recipientMachineID string) (LinkedKeys, error) {
+	keys, err := localKeyMaterial()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	trusted, err := LoadTrustedMachines()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	devicePub, err := GetOrCreateDevicePublicKey()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	name, _ := os.Hostname()
+
+	keys.Trusted = map[string]TrustedMachine{}
+	for id, m := range trusted.Machines {
+		if id != recipientMachineID {
+			keys.Trusted[id] = m
+		}
+	}
+	self := keys.Identities[0].MachineID
+	keys.Trusted[self] = TrustedMachine{
+		Name:         strings.TrimSpace(name),
+		DevicePubKey: devicePub,
+		ApprovedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	return keys, nil
+}
+
+// localKeyMaterial returns this machine's keys plus any it was linked with.
+// It is what `sentra link` hands to a new machine and what a recovery kit holds.
+func localKeyMaterial() (LinkedKeys, error) {
+	cfg, err := EnsureConfig()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	sessionKey, err := getOrCreateSessionKey()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	priv, err := GetOrCreateDeviceEncryptionKey()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+
+	keys := LinkedKeys{
+		V:           1,
+		SessionKeys: []string{base64.RawURLEncoding.EncodeToString(sessionKey)},
+		Identities: []LinkedIdentity{{
+			MachineID: cfg.MachineID,
+			Key:       base64.RawURLEncoding.EncodeToString(priv.Bytes()),
+		}},
+	}
+	if linked, err := LoadLinkedKeys(); err == nil {
+		keys.merge(linked)
+	}
+	return keys, nil
+}
+
+// OpenLinkBundle decrypts a bundle sealed to this machine and stores its keys.
+func OpenLinkBundle(bundle string) (LinkedKeys, error) {
+	cfg, err := EnsureConfig()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(bundle))
+	if err != nil {
+		return LinkedKeys{}, fmt.Errorf("invalid link bundle: %w", err)
+	}
+	var box x25519Box
+	if err := json.Unmarshal(raw, &box); err != nil {
+		return LinkedKeys{}, fmt.Errorf("invalid link bundle: %w", err)
+	}
+	priv, err := GetOrCreateDeviceEncryptionKey()
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	plain, err := openX25519Box(priv, box, linkBundleInfo, []byte(cfg.MachineID))
+	if err != nil {
+		return LinkedKeys{}, errors.New("cannot decrypt link bundle (was it sealed to another machine?)")
+	}
+	keys, err := parseLinkedKeys(plain)
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	if err := AddLinkedKeys(keys); err != nil {
+		return LinkedKeys{}, err
+	}
+	if err := trustLinkedMachines(cfg.MachineID, keys); err != nil {
+		return LinkedKeys{}, err
+	}
+	return keys, nil
+}
+
+// trustLinkedMachines pins the machines a link bundle vouches for. The user
+// checked the approving machine's link code, and the bundle is sealed to
+// this machine, so its pins are as good as ones made here. Pins already
+// made here are kept.
+func trustLinkedMachines(selfID string, keys LinkedKeys) error {
+	trusted, err := LoadTrustedMachines()
+	if err != nil {
+		return err
+	}
+	for id, m := range keys.Trusted {
+		if id == selfID || strings.TrimSpace(m.DevicePubKey) == "" {
+			continue
+		}
+		if _, ok := trusted.Machines[id]; ok {
+			continue
+		}
+		if err := TrustMachine(id, m.Name, m.DevicePubKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestLinkBundleCarriesTrust(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+
+	// The approving machine trusts another machine and the new one.
+	if err := TrustMachine("m-other", "desktop", "other-device-key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TrustMachine("m-new", "laptop", "new-device-key"); err != nil {
+		t.Fatal(err)
+	}
+	approver, err := EnsureConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	approverDevice, err := GetOrCreateDevicePublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := linkBundleKeys("m-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.Trusted) != 2 || keys.Trusted[approver.MachineID].DevicePubKey != approverDevice || keys.Trusted["m-other"].DevicePubKey != "other-device-key" {
+		t.Fatalf("bundle trusts %+v, want the approver and m-other", keys.Trusted)
+	}
+
+	// The new machine pins them, keeping a pin it already had.
+	t.Setenv("HOME", t.TempDir())
+	if err := TrustMachine("m-other", "desktop", "pinned-here"); err != nil {
+		t.Fatal(err)
+	}
+	if err := trustLinkedMachines("m-new", keys); err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := LoadTrustedMachines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trusted.Machines[approver.MachineID].DevicePubKey; got != approverDevice {
+		t.Fatalf("approver pinned to %q, want %q", got, approverDevice)
+	}
+	if got := trusted.Machines["m-other"].DevicePubKey; got != "pinned-here" {
+		t.Fatalf("m-other pinned to %q, want the existing pin", got)
+	}
+	if _, ok := trusted.Machines["m-new"]; ok {
+		t.Fatal("the new machine trusts itself")
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	linkedKeysKeyringUser = "linked-keys"
+)
+
+// LinkedKeys is key material received from other machines via `sentra link`.
+// It lets this machine read blobs that were pushed before it was registered.
+type LinkedKeys struct {
+	V int `json:"v"`
+	// SessionKeys are other machines' per-installation keys (legacy cipher).
+	SessionKeys []string `json:"session_keys,omitempty"`
+	// Identities are other machines' X25519 keys, by machine id.
+	Identities []LinkedIdentity `json:"identities,omitempty"`
+	// Trusted are the machines the sender approved, itself included, by
+	// machine id. Only link bundles carry them (see trustLinkedMachines); they
+	// are not stored with the keys.
+	Trusted map[string]TrustedMachine `json:"trusted,omitempty"`
+}
+
+type LinkedIdentity struct {
+	MachineID string `json:"machine_id"`
+	Key       string `json:"key"`
+}
+
+// merge adds keys from o that aren't present yet. It reports whether anything changed.
+func (k *LinkedKeys) merge(o LinkedKeys) bool {
+	changed := false
+	for _, s := range o.SessionKeys {
+		s = strings.TrimSpace(s)
+		if s == "" || containsString(k.SessionKeys, s) {
+			continue
+		}
+		k.SessionKeys = append(k.SessionKeys, s)
+		changed = true
+	}
+	for _, id := range o.Identities {
+		id.MachineID = strings.TrimSpace(id.MachineID)
+		id.Key = strings.TrimSpace(id.Key)
+		if id.MachineID == "" || id.Key == "" {
+			continue
+		}
+		dup := false
+		for _, have := range k.Identities {
+			if have.Key == id.Key {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		k.Identities = append(k.Identities, id)
+		changed = true
+	}
+	return changed
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func LoadLinkedKeys() (LinkedKeys, error) {
+	// 1) Prefer OS keyring.
+	v, err := keyring.Get(keyringService, linkedKeysKeyringUser)
+	if err == nil && strings.TrimSpace(v) != "" {
+		return parseLinkedKeys([]byte(v))
+	}
+
+	// 2) Fallback: local file (0600).
+	p, pathErr := linkedKeysPath()
+	if pathErr != nil {
+		return LinkedKeys{}, pathErr
+	}
+	b, readErr := os.ReadFile(p)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return LinkedKeys{V: 1}, nil
+		}
+		return LinkedKeys{}, readErr
+	}
+	return parseLinkedKeys(b)
+}
+
+// AddLinkedKeys merges k into the stored linked keys.
+func AddLinkedKeys(k LinkedKeys) error {
+	cur, err := LoadLinkedKeys()
+	if err != nil {
+		return err
+	}
+	if !cur.merge(k) {
+		return nil
+	}
+	cur.V = 1
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Set(keyringService, linkedKeysKeyringUser, string(b)); err == nil {
+		return nil
+	}
+
+	p, err := linkedKeysPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmpPath := p + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+func parseLinkedKeys(b []byte) (LinkedKeys, error) {
+	var k LinkedKeys
+	if err := json.Unmarshal(b, &k); err != nil {
+		return LinkedKeys{}, errors.New("invalid linked keys")
+	}
+	if k.V == 0 {
+		k.V = 1
+	}
+	return k, nil
+}
+
+func linkedKeysPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sentra", "linked.keys"), nil
+}
+
+// linkedSessionKeys returns the decoded legacy keys; invalid entries are skipped.
+func linkedSessionKeys() [][]byte {
+	k, err := LoadLinkedKeys()
+	if err != nil {
+		return nil
+	}
+	var out [][]byte
+	for _, s := range k.SessionKeys {
+		raw, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(raw) != 32 {
+			continue
+		}
+		out = append(out, raw)
+	}
+	return out
+}
+
+// linkedIdentities returns the linked X25519 keys by machine id; invalid entries are skipped.
+func linkedIdentities() map[string][]*ecdh.PrivateKey {
+	k, err := LoadLinkedKeys()
+	if err != nil {
+		return nil
+	}
+	out := map[string][]*ecdh.PrivateKey{}
+	for _, id := range k.Identities {
+		priv, err := parseDeviceEncKey(id.Key)
+		if err != nil {
+			continue
+		}
+		out[id.MachineID] = append(out[id.MachineID], priv)
+	}
+	return out
+}
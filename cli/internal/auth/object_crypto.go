@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// SealLocalObject encrypts a blob for the local object store using the
+// per-installation key. The object id is bound as associated data so a blob
+// cannot be swapped for another one on disk without failing to open.
+func SealLocalObject(id string, plain []byte) ([]byte, error) {
+	gcm, err := localObjectAEAD()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ct := gcm.Seal(nil, nonce, plain, []byte(id))
+	return append(nonce, ct...), nil
+}
+
+// OpenLocalObject decrypts a blob written by SealLocalObject.
+func OpenLocalObject(id string, raw []byte) ([]byte, error) {
+	gcm, err := localObjectAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid object ciphertext")
+	}
+
+	nonce := raw[:gcm.NonceSize()]
+	ct := raw[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ct, []byte(id))
+}
+
+func localObjectAEAD() (cipher.AEAD, error) {
+	key, err := getOrCreateSessionKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid encryption key length")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
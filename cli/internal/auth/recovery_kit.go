@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Recovery kit format (v1):
+//
+//	"SRK1" || salt(16) || nonce(12) || AES-256-GCM(key, LinkedKeys JSON)
+//
+// key = Argon2id(passphrase, salt, t=3, m=64 MiB, p=4). The kit is written
+// either as an armored file or as a grouped base32 recovery code for paper.
+const (
+	recoveryMagic     = "SRK1"
+	recoverySaltLen   = 16
+	recoveryArgonTime = 3
+	recoveryArgonMem  = 64 * 1024
+	recoveryArgonPar  = 4
+
+	recoveryArmorBegin = "-----BEGIN SENTRA RECOVERY KIT-----"
+	recoveryArmorEnd   = "-----END SENTRA RECOVERY KIT-----"
+
+	// MinRecoveryPassphraseLen is enforced on export only.
+	MinRecoveryPassphraseLen = 12
+)
+
+var (
+	ErrRecoveryPassphrase = errors.New("wrong passphrase or corrupted recovery kit")
+
+	recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// ExportRecoveryKit seals this machine's key material (including linked keys)
+// under passphrase. It does not write anything; see RecoveryKitArmor/RecoveryCode.
+func ExportRecoveryKit(passphrase string) ([]byte, error) {
+	if len(passphrase) < MinRecoveryPassphraseLen {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinRecoveryPassphraseLen)
+	}
+	keys, err := localKeyMaterial()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, recoverySaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(recoveryKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(recoveryMagic)+len(salt)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, recoveryMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	out = gcm.Seal(out, nonce, plain, []byte(recoveryMagic))
+	return out, nil
+}
+
+// ImportRecoveryKit decrypts a kit (armored file, recovery code or raw bytes)
+// and adds its keys to this machine's linked keys (keyring, or file fallback).
+func ImportRecoveryKit(data []byte, passphrase string) (LinkedKeys, error) {
+	raw, err := decodeRecoveryKit(data)
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	head := len(recoveryMagic) + recoverySaltLen
+	if len(raw) < head || string(raw[:len(recoveryMagic)]) != recoveryMagic {
+		return LinkedKeys{}, errors.New("not a sentra recovery kit")
+	}
+	salt := raw[len(recoveryMagic):head]
+
+	gcm, err := newAESGCM(recoveryKey(passphrase, salt))
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	if len(raw) < head+gcm.NonceSize()+gcm.Overhead() {
+		return LinkedKeys{}, errors.New("truncated recovery kit")
+	}
+	nonce := raw[head : head+gcm.NonceSize()]
+	plain, err := gcm.Open(nil, nonce, raw[head+gcm.NonceSize():], []byte(recoveryMagic))
+	if err != nil {
+		return LinkedKeys{}, ErrRecoveryPassphrase
+	}
+
+	keys, err := parseLinkedKeys(plain)
+	if err != nil {
+		return LinkedKeys{}, err
+	}
+	if err := AddLinkedKeys(keys); err != nil {
+		return LinkedKeys{}, err
+	}
+	return keys, nil
+}
+
+// RecoveryKitArmor renders a kit as a text file.
+func RecoveryKitArmor(kit []byte, machineID string, createdAt time.Time) []byte {
+	var b bytes.Buffer
+	b.WriteString(recoveryArmorBegin + "\n")
+	b.WriteString("Machine: " + strings.TrimSpace(machineID) + "\n")
+	b.WriteString("Created: " + createdAt.UTC().Format(time.RFC3339) + "\n")
+	b.WriteString("\n")
+	enc := base64.StdEncoding.EncodeToString(kit)
+	for len(enc) > 64 {
+		b.WriteString(enc[:64] + "\n")
+		enc = enc[64:]
+	}
+	if enc != "" {
+		b.WriteString(enc + "\n")
+	}
+	b.WriteString(recoveryArmorEnd + "\n")
+	return b.Bytes()
+}
+
+// RecoveryCode renders a kit as base32 in groups of 4, 8 groups per line,
+// for writing down. `sentra key import` accepts it back verbatim.
+func RecoveryCode(kit []byte) string {
+	enc := recoveryCodeEncoding.EncodeToString(kit)
+	var b strings.Builder
+	for i := 0; i < len(enc); i += 4 {
+		end := i + 4
+		if end > len(enc) {
+			end = len(enc)
+		}
+		if i > 0 {
+			if (i/4)%8 == 0 {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(enc[i:end])
+	}
+	return b.String()
+}
+
+func decodeRecoveryKit(data []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(data))
+	if s == "" {
+		return nil, errors.New("empty recovery kit")
+	}
+
+	if strings.HasPrefix(s, recoveryArmorBegin) {
+		end := strings.Index(s, recoveryArmorEnd)
+		if end < 0 {
+			return nil, errors.New("truncated recovery kit")
+		}
+		body := s[len(recoveryArmorBegin):end]
+		var b64 strings.Builder
+		for _, line := range strings.Split(body, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.Contains(line, ":") {
+				continue // headers
+			}
+			b64.WriteString(line)
+		}
+		raw, err := base64.StdEncoding.DecodeString(b64.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid recovery kit: %w", err)
+		}
+		return raw, nil
+	}
+
+	// Recovery code: ignore whitespace and dashes, tolerate lower case.
+	code := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '-':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(s))
+	raw, err := recoveryCodeEncoding.DecodeString(code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recovery code: %w", err)
+	}
+	return raw, nil
+}
+
+func recoveryKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, recoveryArgonTime, recoveryArgonMem, recoveryArgonPar, 32)
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TrustedMachines are the machines the user approved on this one, with
+// `sentra machines approve` or `sentra machines trust`. Push wraps content
+// keys only to them: each one's device key is pinned here, and its
+// encryption key is used only if signed by that device key.
+type TrustedMachines struct {
+	V        int                       `json:"v"`
+	Machines map[string]TrustedMachine `json:"machines"`
+}
+
+type TrustedMachine struct {
+	Name         string `json:"name,omitempty"`
+	DevicePubKey string `json:"device_pub_key"`
+	ApprovedAt   string `json:"approved_at"`
+}
+
+func trustedMachinesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sentra", "trusted-machines.json"), nil
+}
+
+func LoadTrustedMachines() (TrustedMachines, error) {
+	p, err := trustedMachinesPath()
+	if err != nil {
+		return TrustedMachines{}, err
+	}
+	t := TrustedMachines{V: 1, Machines: map[string]TrustedMachine{}}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return TrustedMachines{}, err
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return TrustedMachines{}, errors.New("invalid trusted machines file")
+	}
+	if t.Machines == nil {
+		t.Machines = map[string]TrustedMachine{}
+	}
+	return t, nil
+}
+
+// TrustMachine pins devicePubKey for machineID, replacing any earlier pin.
+func TrustMachine(machineID, name, devicePubKey string) error {
+	machineID = strings.TrimSpace(machineID)
+	devicePubKey = strings.TrimSpace(devicePubKey)
+	if machineID == "" || devicePubKey == "" {
+		return errors.New("missing machine id or device key")
+	}
+	t, err := LoadTrustedMachines()
+	if err != nil {
+		return err
+	}
+	t.V = 1
+	t.Machines[machineID] = TrustedMachine{
+		Name:         strings.TrimSpace(name),
+		DevicePubKey: devicePubKey,
+		ApprovedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	p, err := trustedMachinesPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmpPath := p + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
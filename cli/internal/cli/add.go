@@ -3,17 +3,21 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/mgeovany/sentra/cli/internal/commit"
 	"github.com/mgeovany/sentra/cli/internal/index"
 	"github.com/mgeovany/sentra/cli/internal/scanner"
 )
 
+const addUsage = "usage: sentra add . | sentra add <path|project|glob>..."
+
 func runAdd(args []string) error {
 	if len(args) == 0 {
-		return errors.New("usage: sentra add . | sentra add <path>")
+		return errors.New(addUsage)
 	}
 
 	verbosef("Starting add operation...")
@@ -24,7 +28,7 @@ func runAdd(args []string) error {
 	}
 	verbosef("Scan root: %s", scanRoot)
 
-	projects, err := scanner.Scan(scanRoot)
+	projects, err := scanProjects(scanRoot)
 	if err != nil {
 		return err
 	}
@@ -32,6 +36,10 @@ func runAdd(args []string) error {
 
 	available := flattenScan(scanRoot, projects)
 	verbosef("Found %d available env file(s)", len(available))
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		return err
+	}
 
 	indexPath, err := index.DefaultPath()
 	if err != nil {
@@ -47,8 +55,38 @@ func runAdd(args []string) error {
 		idx.Staged = map[string]string{}
 	}
 
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+	tracked, err := trackedPaths(commits)
+	if err != nil {
+		return err
+	}
+
 	switch args[0] {
 	case ".":
+		// Tracked (or staged) files gone from disk are staged as deletions.
+		var deleted []string
+		for _, p := range unionKeys(sortedKeys(tracked), sortedKeys(idx.Staged)) {
+			if _, ok := available[p]; ok {
+				continue
+			}
+			delete(idx.Staged, p)
+			delete(idx.Objects, p)
+			if tracked[p] {
+				idx.StageDeletion(p)
+				deleted = append(deleted, p)
+				verbosef("  - %s (deleted)", p)
+			}
+		}
+		if len(deleted) > 0 && len(available) == 0 {
+			if err := index.Save(indexPath, idx); err != nil {
+				return err
+			}
+			fmt.Println(c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(deleted))) + c(ansiGreen, " deletion(s)"))
+			return nil
+		}
 		if len(available) == 0 {
 			fmt.Println(c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, "0") + c(ansiGreen, " env files"))
 			verbosef("No env files found to stage")
@@ -64,45 +102,119 @@ func runAdd(args []string) error {
 			verbosef("  - %s (hash: %s)", p, available[p])
 		}
 
+		staged := make(map[string]string, len(paths))
 		for _, p := range paths {
-			idx.Staged[p] = available[p]
+			staged[p] = available[p]
+		}
+		if err := stageFiles(scanRoot, &idx, staged); err != nil {
+			return err
 		}
 		if err := index.Save(indexPath, idx); err != nil {
 			return err
 		}
-		fmt.Println(c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(paths))) + c(ansiGreen, " env files"))
+		msg := c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(paths))) + c(ansiGreen, " env files")
+		if len(deleted) > 0 {
+			msg += c(ansiGreen, ", ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(deleted))) + c(ansiGreen, " deletion(s)")
+		}
+		fmt.Println(msg)
 		verbosef("Index saved to: %s", indexPath)
 		return nil
 	default:
-		if len(args) != 1 {
-			return errors.New("usage: sentra add . | sentra add <path>")
-		}
-
-		requested := normalizeRelPath(args[0])
-		verbosef("Looking for file: %s", requested)
-		hash, ok := available[requested]
-		if !ok {
-			// allow `./foo/bar` too
-			requested = strings.TrimPrefix(requested, "./")
-			verbosef("Trying without ./ prefix: %s", requested)
-			hash, ok = available[requested]
-			if !ok {
-				verbosef("File not found in available files")
-				return fmt.Errorf("env file not found: %s", args[0])
+		staged := map[string]string{}
+		var deleted []string
+		for _, arg := range args {
+			spec := strings.TrimPrefix(normalizeRelPath(arg), "./")
+			verbosef("Looking for files matching: %s", spec)
+			matched := false
+			for p, hash := range available {
+				if matchPathspec(ids, spec, p) {
+					staged[p] = hash
+					matched = true
+				}
+			}
+			// Tracked files gone from disk are staged as deletions.
+			for _, p := range sortedKeys(tracked) {
+				if _, ok := available[p]; !ok && matchPathspec(ids, spec, p) {
+					deleted = append(deleted, p)
+					matched = true
+				}
 			}
+			if !matched {
+				verbosef("No available or tracked file matches")
+				return fmt.Errorf("env file not found: %s", arg)
+			}
+		}
+		deleted = unionKeys(deleted)
+		for _, p := range sortedKeys(staged) {
+			verbosef("  - %s (hash: %s)", p, staged[p])
+		}
+		for _, p := range deleted {
+			idx.StageDeletion(p)
+			verbosef("  - %s (deleted)", p)
 		}
-		verbosef("Found file: %s (hash: %s)", requested, hash)
 
-		idx.Staged[requested] = hash
+		if err := stageFiles(scanRoot, &idx, staged); err != nil {
+			return err
+		}
 		if err := index.Save(indexPath, idx); err != nil {
 			return err
 		}
-		fmt.Println(c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, requested))
+		switch {
+		case len(staged) == 1 && len(deleted) == 0:
+			fmt.Println(c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, sortedKeys(staged)[0]))
+		case len(staged) == 0 && len(deleted) == 1:
+			fmt.Println(c(ansiGreen, "✔ staged deletion of ") + c(ansiBoldCyan, deleted[0]))
+		default:
+			msg := c(ansiGreen, "✔ staged ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(staged))) + c(ansiGreen, " env files")
+			if len(deleted) > 0 {
+				msg += c(ansiGreen, ", ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(deleted))) + c(ansiGreen, " deletion(s)")
+			}
+			fmt.Println(msg)
+		}
 		verbosef("Index saved to: %s", indexPath)
 		return nil
 	}
 }
 
+// matchPathspec reports whether the full path p ("root/.env") is selected by
+// spec: the path itself, a project or directory containing it, or a glob
+// matched against the full path, the path inside the project or the file name.
+func matchPathspec(ids *projectIDs, spec string, p string) bool {
+	if strings.ContainsAny(spec, "*?[") {
+		if ok, _ := path.Match(spec, p); ok {
+			return true
+		}
+		return matchAnyGlob([]string{spec}, ids.relInProject(p))
+	}
+	return spec == "." || p == spec || strings.HasPrefix(p, spec+"/")
+}
+
+// stageFiles records files in the index along with a snapshot of their
+// current contents, so the staged version can be diffed later even if the
+// working copy changes.
+func stageFiles(scanRoot string, idx *index.Index, files map[string]string) error {
+	snapshots, err := snapshotStagedFiles(scanRoot, files)
+	if err != nil {
+		return err
+	}
+	if idx.Objects == nil {
+		idx.Objects = map[string]string{}
+	}
+	for p, hash := range files {
+		idx.Staged[p] = hash
+		idx.Objects[p] = snapshots[p]
+	}
+	// A file that is back on disk is no longer being deleted.
+	kept := idx.Deleted[:0]
+	for _, p := range idx.Deleted {
+		if _, ok := files[p]; !ok {
+			kept = append(kept, p)
+		}
+	}
+	idx.Deleted = kept
+	return nil
+}
+
 func flattenScan(scanRoot string, projects []scanner.Project) map[string]string {
 	out := make(map[string]string)
 	for _, p := range projects {
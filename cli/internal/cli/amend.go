@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/index"
+)
+
+const squashUsage = "usage: sentra log squash <id>..[<id>] [-m 'message']"
+
+// runCommitAmend folds the staged files, and the new message if given, into
+// the latest commit. Pushed commits are part of remote history and refused.
+func runCommitAmend(message string) error {
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return errors.New("nothing to amend (no commits)")
+	}
+	last := commits[len(commits)-1]
+	if strings.TrimSpace(last.PushedAt) != "" {
+		return fmt.Errorf("latest commit %s is already pushed; only pending commits can be amended", shortCommitID(last))
+	}
+
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		return err
+	}
+	idx, _, err := index.Load(indexPath)
+	if err != nil {
+		return err
+	}
+	if idx.Empty() && message == "" {
+		return errors.New("nothing to amend (no staged env files and no -m)")
+	}
+
+	var scanRoot string
+	if !idx.Empty() {
+		if err := runPreCommitChecks(); err != nil {
+			return fmt.Errorf("pre-commit checks failed: %w", err)
+		}
+		scanRoot = strings.TrimSpace(idx.ScanRoot)
+		if scanRoot == "" {
+			if scanRoot, err = resolveScanRootFromIndex(); err != nil {
+				return err
+			}
+		}
+		snapshots, err := stagedSnapshots(scanRoot, &idx)
+		if err != nil {
+			return err
+		}
+		staged := commit.Commit{Files: idx.Staged, Objects: snapshots, Deleted: idx.Deleted}
+		if staged.Parents, err = amendParents(last, staged.Paths()); err != nil {
+			return err
+		}
+		last.Fold(staged)
+		verbosef("Folded %d staged file(s), %d deletion(s) into %s", len(idx.Staged), len(idx.Deleted), last.ID)
+	}
+	if message != "" {
+		last.Message = message
+	}
+
+	if err := commit.Update(last); err != nil {
+		return err
+	}
+	if !idx.Empty() {
+		if err := updateBaseline(scanRoot, idx.Staged, nil, false); err != nil {
+			verbosef("Failed to update status baseline: %v", err)
+		}
+		idx.Staged = map[string]string{}
+		idx.Objects = nil
+		idx.Deleted = nil
+		if err := index.Save(indexPath, idx); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(c(ansiGreen, "✔ amended ") + c(ansiBoldCyan, last.ID[:8]))
+	return nil
+}
+
+// amendParents picks the parents of the projects that paths bring into last:
+// they chain like a fresh commit would. last is itself the newest pending
+// commit of its own projects, and is never its own parent.
+func amendParents(last commit.Commit, paths []string) (map[string]string, error) {
+	parents, err := commitParents(paths)
+	if err != nil {
+		return nil, err
+	}
+	for root, parent := range parents {
+		if parent == last.ID {
+			delete(parents, root)
+		}
+	}
+	return parents, nil
+}
+
+// sentra log squash <id>..[<id>] [-m 'message']
+//
+// Folds a range of pending commits, both ends included, into the oldest one.
+// Without an end the range runs to the newest commit.
+func runLogSquash(args []string) error {
+	var spec, message string
+	hasMessage := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-m":
+			if i+1 >= len(args) {
+				return errors.New(squashUsage)
+			}
+			message = strings.TrimSpace(args[i+1])
+			hasMessage = true
+			i++
+		case spec == "" && strings.Contains(args[i], ".."):
+			spec = args[i]
+		default:
+			return errors.New(squashUsage)
+		}
+	}
+	if spec == "" {
+		return errors.New(squashUsage)
+	}
+	if hasMessage && message == "" {
+		return errors.New("commit message cannot be empty")
+	}
+
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return errors.New("no commits")
+	}
+
+	from, to, _ := strings.Cut(spec, "..")
+	start, err := commitPosition(commits, from)
+	if err != nil {
+		return err
+	}
+	end := len(commits) - 1
+	if strings.TrimSpace(to) != "" {
+		if end, err = commitPosition(commits, to); err != nil {
+			return err
+		}
+	}
+	if end < start {
+		return fmt.Errorf("%s is older than %s; list the oldest commit first", strings.TrimSpace(to), strings.TrimSpace(from))
+	}
+	if end == start {
+		return errors.New("nothing to squash (range has one commit)")
+	}
+
+	squashed := commits[start : end+1]
+	ids := map[string]bool{}
+	for _, cm := range squashed {
+		if strings.TrimSpace(cm.PushedAt) != "" {
+			return fmt.Errorf("commit %s is already pushed; only pending commits can be squashed", shortCommitID(cm))
+		}
+		ids[cm.ID] = true
+	}
+
+	base := squashed[0]
+	messages := []string{strings.TrimSpace(base.Message)}
+	for _, cm := range squashed[1:] {
+		base.Fold(cm)
+		if m := strings.TrimSpace(cm.Message); m != "" {
+			messages = append(messages, m)
+		}
+	}
+	for root, parent := range base.Parents {
+		if ids[parent] {
+			delete(base.Parents, root)
+		}
+	}
+	base.Message = strings.Join(messages, "; ")
+	if hasMessage {
+		base.Message = message
+	}
+
+	if err := commit.Update(base); err != nil {
+		return err
+	}
+	for _, cm := range squashed[1:] {
+		if err := commit.Delete(cm.ID); err != nil {
+			return err
+		}
+	}
+	// Later commits chained onto a squashed one now chain onto the result.
+	for _, cm := range commits[end+1:] {
+		changed := false
+		for root, parent := range cm.Parents {
+			if ids[parent] && parent != base.ID {
+				cm.Parents[root] = base.ID
+				changed = true
+			}
+		}
+		if changed {
+			if err := commit.Update(cm); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Println(c(ansiGreen, fmt.Sprintf("✔ squashed %d commits into ", len(squashed))) + c(ansiBoldCyan, base.ID[:8]))
+	return nil
+}
+
+func commitPosition(commits []commit.Commit, selector string) (int, error) {
+	id, err := resolveCommitID(commits, selector)
+	if err != nil {
+		return 0, err
+	}
+	for i, cm := range commits {
+		if cm.ID == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", errCommitNotFound, selector)
+}
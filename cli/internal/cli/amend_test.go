@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+)
+
+func TestAmendFirstCommitOfProject(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	for _, root := range []string{"api", "web"} {
+		if err := os.MkdirAll(filepath.Join(scanRoot, root, ".git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(scanRoot, root, ".env"), "A=1\n")
+	}
+	writeTestFile(t, filepath.Join(scanRoot, "api", ".env.local"), "B=1\n")
+	if err := commit.SetHead("web", "web-head"); err != nil {
+		t.Fatal(err)
+	}
+
+	// api's first commit: nothing to chain onto.
+	first := layoutCommit(t, scanRoot, "api/.env")
+	if _, err := commit.Save(first); err != nil {
+		t.Fatal(err)
+	}
+
+	staged := layoutCommit(t, scanRoot, "api/.env.local", "web/.env")
+	parents, err := amendParents(first, staged.Paths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	staged.Parents = parents
+	first.Fold(staged)
+	if got := first.ParentFor("api"); got != "" {
+		t.Fatalf("api parent = %q, want none (the commit is its own parent)", got)
+	}
+	// A project the amend brings in still chains onto its remote head.
+	if got := first.ParentFor("web"); got != "web-head" {
+		t.Fatalf("web parent = %q, want web-head", got)
+	}
+}
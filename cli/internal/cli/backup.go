@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupDir returns a fresh directory under ~/.sentra/backups for one run.
+func backupDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	stamp := time.Now().UTC().Format("20060102T150405.000000000Z")
+	return filepath.Join(homeDir, ".sentra", "backups", stamp), nil
+}
+
+// fileBackups copies files into one backup directory before they are
+// overwritten. The directory is only created on first use.
+type fileBackups struct {
+	dir   string
+	count int
+}
+
+// save copies scanRoot/rel into the backup directory. Missing files are
+// not an error: there is nothing to lose.
+func (b *fileBackups) save(scanRoot string, rel string) error {
+	src := filepath.Join(scanRoot, filepath.FromSlash(rel))
+	data, err := os.ReadFile(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if b.dir == "" {
+		dir, err := backupDir()
+		if err != nil {
+			return err
+		}
+		b.dir = dir
+	}
+	dst := filepath.Join(b.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0o600); err != nil {
+		return err
+	}
+	b.count++
+	verbosef("Backed up %s to %s", rel, dst)
+	return nil
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+)
+
+// projectCipher is the cipher push uses for the project at projectDir: the
+// `cipher` key of its .sentra.yml, else the scan root's, else
+// auth.DefaultEnvCipher. Being in the project's config, the choice is shared
+// by every machine that checks the project out.
+func projectCipher(scanRoot, projectDir string) (string, error) {
+	name, err := configuredCipher(scanRoot, projectDir)
+	if err != nil || name != "" {
+		return name, err
+	}
+	return auth.DefaultEnvCipher, nil
+}
+
+// configuredCipher is the `cipher` set for the project at projectDir, or ""
+// if neither its config nor the scan root's sets one.
+func configuredCipher(scanRoot, projectDir string) (string, error) {
+	cfg, err := scanner.ProjectConfig(scanRoot, projectDir)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(cfg.Cipher)
+	if name != "" && !auth.IsWritableEnvCipher(name) {
+		return "", fmt.Errorf("%s: unsupported cipher %s in .sentra.yml (use one of: %s)", projectDir, name, strings.Join(auth.WritableEnvCiphers, ", "))
+	}
+	return name, nil
+}
+
+// legacyCipherName is how .sentra.yml names the original per-machine format,
+// reported by the server as auth.CipherLegacy or no cipher at all.
+const legacyCipherName = "legacy"
+
+// checkRemoteCipher guards against the server serving a file under a cipher
+// that is not bound to its path, which would let it pass off another file's
+// blob as this one. The cipher tag comes from the server, so a sentra-v2
+// blob could otherwise be swapped for an older-format one. Such files are
+// refused unless the project opts into the cipher: by pushing with it
+// (`cipher: age-v1`) or by listing it in `legacy_ciphers`.
+func checkRemoteCipher(id string, f remoteExportFile) error {
+	name := strings.TrimSpace(f.Cipher)
+	if auth.IsBoundEnvCipher(name) {
+		return nil
+	}
+	if name == "" || name == auth.CipherLegacy {
+		name = legacyCipherName
+	}
+	ids, err := currentProjectIDs()
+	if err != nil {
+		return err
+	}
+	dir := ids.scanRoot
+	if root, ok := ids.localRoot(id); ok {
+		dir = filepath.Join(ids.scanRoot, filepath.FromSlash(root))
+	}
+	configured, err := configuredCipher(ids.scanRoot, dir)
+	if err != nil {
+		return err
+	}
+	if configured == name {
+		return nil
+	}
+	cfg, err := scanner.ProjectConfig(ids.scanRoot, dir)
+	if err != nil {
+		return err
+	}
+	for _, c := range cfg.LegacyCiphers {
+		if strings.TrimSpace(c) == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing %s: served as %s, which is not bound to its path (push it again, or add %s to legacy_ciphers in .sentra.yml if it really is %s)", strings.TrimSpace(f.Path), name, name, name)
+}
+
+// warnLegacyCiphersFile points out a ~/.sentra/ciphers.json left from when
+// the choice was stored per machine. It is no longer read.
+func warnLegacyCiphersFile() {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	p := filepath.Join(homeDir, ".sentra", "ciphers.json")
+	if _, err := os.Stat(p); err == nil {
+		warnf("%s is no longer read; set the cipher in the project's .sentra.yml (sentra cipher <project> <cipher>) and delete it", p)
+	}
+}
+
+func runCipher(args []string) error {
+	usage := errors.New("usage: sentra cipher [<project> [" + strings.Join(auth.WritableEnvCiphers, "|") + "]]")
+	if len(args) > 2 {
+		return usage
+	}
+
+	scanRoot, err := resolveScanRoot()
+	if err != nil {
+		return err
+	}
+	warnLegacyCiphersFile()
+
+	if len(args) == 0 {
+		name, err := projectCipher(scanRoot, scanRoot)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("default: %s\n", name)
+		projects, err := scanProjects(scanRoot)
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			name, err := projectCipher(scanRoot, p.RootPath)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(scanRoot, p.RootPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: %s\n", filepath.ToSlash(rel), name)
+		}
+		return nil
+	}
+
+	root := strings.Trim(strings.TrimSpace(args[0]), "/")
+	if root == "" {
+		return usage
+	}
+	dir := filepath.Join(scanRoot, filepath.FromSlash(root))
+	if !isDir(dir) {
+		return fmt.Errorf("no project %s under %s", root, scanRoot)
+	}
+	if len(args) == 1 {
+		name, err := projectCipher(scanRoot, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Println(name)
+		return nil
+	}
+
+	name := strings.TrimSpace(args[1])
+	if !auth.IsWritableEnvCipher(name) {
+		return fmt.Errorf("unsupported cipher: %s (use one of: %s)", name, strings.Join(auth.WritableEnvCiphers, ", "))
+	}
+	p, err := scanner.SetConfigValue(dir, "cipher", name)
+	if err != nil {
+		return err
+	}
+	successf("✔ %s will be pushed with %s", root, name)
+	infof("Set in %s; commit it so other machines push with it too.", p)
+	if name == auth.CipherAgeV1 {
+		fmt.Println("Decrypt offline with: sentra key identity --out key.txt && age -d -i key.txt <blob>")
+	}
+	return nil
+}
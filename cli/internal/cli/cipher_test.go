@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+)
+
+func TestProjectCipher(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	api := filepath.Join(scanRoot, "api")
+	web := filepath.Join(scanRoot, "web")
+	for _, dir := range []string{api, web} {
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := projectCipher(scanRoot, api); err != nil || got != auth.DefaultEnvCipher {
+		t.Fatalf("no config: %q, %v", got, err)
+	}
+
+	// The scan root's choice applies to every project ...
+	writeTestFile(t, filepath.Join(scanRoot, ".sentra.yml"), "cipher: age-v1\n")
+	if got, err := projectCipher(scanRoot, web); err != nil || got != auth.CipherAgeV1 {
+		t.Fatalf("root config: %q, %v", got, err)
+	}
+
+	// ... unless the project's own config says otherwise.
+	writeTestFile(t, filepath.Join(api, ".sentra.yml"), "# detection rules\nenvironments: [qa]\n")
+	p, err := scanner.SetConfigValue(api, "cipher", auth.CipherSentraV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# detection rules\nenvironments: [qa]\ncipher: sentra-v2\n"; string(b) != want {
+		t.Fatalf("%s = %q, want %q", p, b, want)
+	}
+	if got, err := projectCipher(scanRoot, api); err != nil || got != auth.CipherSentraV2 {
+		t.Fatalf("project config: %q, %v", got, err)
+	}
+	if _, err := scanner.SetConfigValue(api, "cipher", auth.CipherAgeV1); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := projectCipher(scanRoot, api); err != nil || got != auth.CipherAgeV1 {
+		t.Fatalf("replaced project config: %q, %v", got, err)
+	}
+
+	writeTestFile(t, filepath.Join(web, ".sentra.yml"), "cipher: rot13\n")
+	if _, err := projectCipher(scanRoot, web); err == nil {
+		t.Fatal("accepted an unknown cipher")
+	}
+}
+
+func TestCheckRemoteCipher(t *testing.T) {
+	home := newTestHome(t)
+	api := filepath.Join(home, "dev", "api")
+	if err := os.MkdirAll(filepath.Join(api, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	bound := remoteExportFile{Path: "api/.env", Cipher: auth.CipherSentraV2}
+	age := remoteExportFile{Path: "api/.env", Cipher: auth.CipherAgeV1}
+	legacy := []remoteExportFile{
+		{Path: "api/.env", Cipher: auth.CipherLegacy},
+		{Path: "api/.env", Cipher: ""},
+	}
+	unbound := append([]remoteExportFile{age, {Path: "api/.env", Cipher: "sentra-v1"}}, legacy...)
+
+	// The default cipher is bound, so nothing else is accepted.
+	if err := checkRemoteCipher("api", bound); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range unbound {
+		if err := checkRemoteCipher("api", f); err == nil {
+			t.Fatalf("%q accepted without opting in", f.Cipher)
+		}
+	}
+
+	// A project pushed with age-v1 expects unbound age blobs, and nothing else.
+	writeTestFile(t, filepath.Join(api, ".sentra.yml"), "cipher: age-v1\n")
+	if err := checkRemoteCipher("api", age); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRemoteCipher("api", legacy[0]); err == nil {
+		t.Fatal("legacy accepted for an age-v1 project")
+	}
+
+	// legacy_ciphers opts into the original format, whichever way it is tagged.
+	writeTestFile(t, filepath.Join(api, ".sentra.yml"), "legacy_ciphers: [legacy]\n")
+	for _, f := range append(legacy, bound) {
+		if err := checkRemoteCipher("api", f); err != nil {
+			t.Fatalf("%q with legacy_ciphers: %v", f.Cipher, err)
+		}
+	}
+	if err := checkRemoteCipher("api", age); err == nil {
+		t.Fatal("age-v1 accepted without being listed")
+	}
+}
@@ -6,11 +6,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-
-	"github.com/mgeovany/sentra/cli/internal/scanner"
 )
 
 func Execute(args []string) error {
+	// --no-cache applies to every command that scans, so it is taken out
+	// before the command parses its own arguments.
+	args, noScanCache = withoutFlag(args, "--no-cache")
 	if len(args) == 0 {
 		return usageError()
 	}
@@ -21,19 +22,26 @@ func Execute(args []string) error {
 			return errors.New("sentra login does not accept flags/args yet")
 		}
 		return runLogin()
+	case "link":
+		return runLink(args[1:])
+	case "machines":
+		return runMachines(args[1:])
+	case "key":
+		return runKey(args[1:])
+	case "cipher":
+		return runCipher(args[1:])
 	case "storage":
 		return runStorage(args[1:])
 	case "export":
 		return runExport(args[1:])
+	case "verify":
+		return runVerify(args[1:])
 	case "files":
 		return runFiles(args[1:])
 	case "commits":
 		return runCommits(args[1:])
 	case "projects":
-		if len(args) > 1 {
-			return errors.New("sentra projects does not accept flags/args yet")
-		}
-		return runProjects()
+		return runProjects(args[1:])
 	case "history":
 		return runHistory(args[1:])
 	case "who":
@@ -51,15 +59,25 @@ func Execute(args []string) error {
 		return runOverview(args[1:])
 	case "add":
 		return runAdd(args[1:])
+	case "reset":
+		return runReset(args[1:])
 	case "status":
 		if len(args) > 1 {
 			return errors.New("sentra status does not accept flags/args yet")
 		}
 		return runStatus()
+	case "diff":
+		return runDiff(args[1:])
 	case "commit":
 		return runCommit(args[1:])
 	case "sync":
 		return runSync(args[1:])
+	case "restore":
+		return runRestore(args[1:])
+	case "revert":
+		return runRevert(args[1:])
+	case "show":
+		return runShow(args[1:])
 	case "log":
 		return runLog(args[1:])
 	case "push":
@@ -80,7 +98,7 @@ func Execute(args []string) error {
 }
 
 func usageError() error {
-	return errors.New("usage: sentra login | sentra storage setup|status|test|reset | sentra projects | sentra history | sentra commits <project> | sentra files <project> [--at <commit>] | sentra export <project> [--at <commit>] | sentra who | sentra scan | sentra overview | sentra add | sentra status | sentra commit | sentra sync | sentra log [all|pending|pushed|rm <id>|clear|prune <id|all>|verify] | sentra push | sentra wipe | sentra doctor")
+	return errors.New("usage: sentra login | sentra link | sentra machines [approve [<machine-id>] | trust <machine-id>] | sentra key export [--out <file>|--print] | sentra key import [<file>] | sentra key identity [--out <file>] | sentra cipher [<project> [sentra-v2|age-v1]] | sentra storage setup|status|test|reset | sentra projects [migrate <folder> | link <folder> <id>] | sentra history | sentra commits <project> | sentra files <project> [--at <commit>] | sentra export <project> [--at <commit>] | sentra verify <project> [--at <commit>] | sentra who | sentra scan | sentra overview | sentra add . | sentra add <path|project|glob>... | sentra reset [<path|project|glob>...] | sentra status | sentra diff [<project>[/<file>]] [--staged|--remote] [--show-values] | sentra commit -m <message> | sentra commit --amend [-m <message>] | sentra sync [<project>...] [--only <glob>] [--exclude <glob>] [--at <commit>] [--dry-run [--json]] [--yes] | sentra restore <project>[/<file>] --at <commit> | sentra revert <commit> [--project <project>] [--to] | sentra show <commit> [--show-values] | sentra log [all|pending|pushed|rm <id>|clear|prune <id|all>|squash <id>..[<id>]|verify] | sentra push | sentra wipe | sentra doctor (scanning commands accept --no-cache)")
 }
 
 func runScan() error {
@@ -93,7 +111,7 @@ func runScan() error {
 
 	sp := startSpinner(fmt.Sprintf("Scanning %s...", scanRoot))
 
-	projects, err := scanner.Scan(scanRoot)
+	projects, err := scanProjects(scanRoot)
 	if err != nil {
 		sp.StopInfo("")
 		return err
@@ -111,16 +129,30 @@ func runScan() error {
 	verbosef("Scan completed: %d project(s), %d env file(s) total", len(projects), envCount)
 
 	projectRoots := make([]string, 0, len(projects))
+	notes := map[string]string{}
 	for _, project := range projects {
 		relProjectRoot, err := filepath.Rel(scanRoot, project.RootPath)
 		if err != nil {
 			return err
 		}
-		projectRoots = append(projectRoots, filepath.ToSlash(strings.TrimPrefix(relProjectRoot, "./")))
+		rel := filepath.ToSlash(strings.TrimPrefix(relProjectRoot, "./"))
+		projectRoots = append(projectRoots, rel)
+		switch {
+		case project.Folded:
+			notes[rel] = "worktree, folded into " + displayProjectPath(scanRoot, project.MainRoot)
+		case project.MainRoot != "":
+			notes[rel] = "worktree of " + displayProjectPath(scanRoot, project.MainRoot)
+		case project.Submodule:
+			notes[rel] = "submodule"
+		}
 	}
 
 	sort.Strings(projectRoots)
 	for _, p := range projectRoots {
+		if note := notes[p]; note != "" {
+			fmt.Println(p + c(ansiDim, " ("+note+")"))
+			continue
+		}
 		fmt.Println(p)
 	}
 
@@ -148,3 +180,12 @@ func runScan() error {
 
 	return nil
 }
+
+// displayProjectPath shows p relative to scanRoot when it is inside it.
+func displayProjectPath(scanRoot, p string) string {
+	rel, err := filepath.Rel(scanRoot, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
@@ -6,18 +6,23 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/mgeovany/sentra/cli/internal/commit"
 	"github.com/mgeovany/sentra/cli/internal/index"
+	"github.com/mgeovany/sentra/cli/internal/objects"
 )
 
 func runCommit(args []string) error {
 	verbosef("Starting commit operation...")
-	message, err := parseCommitMessage(args)
+	message, amend, err := parseCommitArgs(args)
 	if err != nil {
 		return err
 	}
+	if amend {
+		return runCommitAmend(message)
+	}
 	verbosef("Commit message: %s", message)
 
 	indexPath, err := index.DefaultPath()
@@ -30,27 +35,61 @@ func runCommit(args []string) error {
 	if err != nil {
 		return err
 	}
-	if !ok || len(idx.Staged) == 0 {
+	if !ok || idx.Empty() {
 		return errors.New("nothing to commit (no staged env files)")
 	}
 	verbosef("Found %d staged file(s)", len(idx.Staged))
 	for path, hash := range idx.Staged {
 		verbosef("  - %s (hash: %s)", path, hash)
 	}
+	for _, path := range idx.Deleted {
+		verbosef("  - %s (deleted)", path)
+	}
 
 	// Run fmt and lint before committing
 	if err := runPreCommitChecks(); err != nil {
 		return fmt.Errorf("pre-commit checks failed: %w", err)
 	}
 
-	cm := commit.New(message, idx.Staged)
+	scanRoot := strings.TrimSpace(idx.ScanRoot)
+	if scanRoot == "" {
+		if scanRoot, err = resolveScanRootFromIndex(); err != nil {
+			return err
+		}
+	}
+
+	// Commit the contents snapshotted by `sentra add`, so later edits (or
+	// deletions) in the working copy don't change what this commit pushes.
+	snapshots, err := stagedSnapshots(scanRoot, &idx)
+	if err != nil {
+		return err
+	}
+	verbosef("Stored %d object(s) in local object store", len(snapshots))
+
+	cm := commit.New(message, idx.Staged, snapshots)
+	cm.Deleted = append([]string(nil), idx.Deleted...)
+
+	parents, err := commitParents(cm.Paths())
+	if err != nil {
+		return err
+	}
+	cm.Parents = parents
 	verbosef("Created commit: %s", cm.ID)
+	for root, parent := range parents {
+		verbosef("  parent for %s: %s", root, parent)
+	}
 	if _, err := commit.Save(cm); err != nil {
 		return err
 	}
 	verbosef("Commit saved to local storage")
+	// Deletions stay in the baseline until pushed, so status can show them.
+	if err := updateBaseline(scanRoot, idx.Staged, nil, false); err != nil {
+		verbosef("Failed to update status baseline: %v", err)
+	}
 
 	idx.Staged = map[string]string{}
+	idx.Objects = nil
+	idx.Deleted = nil
 	if err := index.Save(indexPath, idx); err != nil {
 		return err
 	}
@@ -62,35 +101,164 @@ func runCommit(args []string) error {
 		shortID = shortID[:8]
 	}
 	fmt.Println(c(ansiGreen, "✔ committed ") + c(ansiBoldCyan, shortID))
-	verbosef("Commit %s created with %d file(s)", cm.ID, len(cm.Files))
+	verbosef("Commit %s created with %d file(s), %d deletion(s)", cm.ID, len(cm.Files), len(cm.Deleted))
 	return nil
 }
 
-func parseCommitMessage(args []string) (string, error) {
-	if len(args) < 2 {
-		return "", errors.New("usage: sentra commit -m 'message'")
+func snapshotStagedFiles(scanRoot string, staged map[string]string) (map[string]string, error) {
+	paths := make([]string, 0, len(staged))
+	for p := range staged {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	out := make(map[string]string, len(paths))
+	for _, p := range paths {
+		abs := filepath.Join(scanRoot, filepath.FromSlash(p))
+		plain, err := os.ReadFile(abs)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("cannot read %s: file not found at %s (run: sentra add . to refresh staged files)", p, abs)
+			}
+			return nil, fmt.Errorf("cannot read %s: %w", p, err)
+		}
+		id, err := objects.Put(plain)
+		if err != nil {
+			return nil, fmt.Errorf("cannot store %s in object store: %w", p, err)
+		}
+		verbosef("  - %s -> object %s", p, id)
+		out[p] = id
+	}
+	return out, nil
+}
+
+// stagedSnapshots returns the snapshot of every staged file, warning about
+// files that changed on disk since they were staged. Files staged before
+// snapshots were kept are snapshotted now, and their staged hash updated.
+func stagedSnapshots(scanRoot string, idx *index.Index) (map[string]string, error) {
+	var current map[string]string
+	if projects, err := scanProjects(scanRoot); err != nil {
+		verbosef("Cannot check staged files against disk: %v", err)
+	} else {
+		current = flattenScan(scanRoot, projects)
+	}
+
+	out := make(map[string]string, len(idx.Staged))
+	legacy := map[string]string{}
+	for _, p := range sortedKeys(idx.Staged) {
+		id := strings.TrimSpace(idx.Objects[p])
+		hash, onDisk := current[p]
+		switch {
+		case id == "":
+			legacy[p] = idx.Staged[p]
+			if onDisk {
+				idx.Staged[p] = hash
+			}
+			continue
+		case current == nil:
+		case !onDisk:
+			warnf("⚠ %s was deleted after it was staged; committing the staged version", p)
+		case hash != idx.Staged[p]:
+			warnf("⚠ %s changed after it was staged; committing the staged version (run: sentra add %s to include the changes)", p, p)
+		}
+		out[p] = id
 	}
 
-	var message string
+	fromDisk, err := snapshotStagedFiles(scanRoot, legacy)
+	if err != nil {
+		return nil, err
+	}
+	for p, id := range fromDisk {
+		out[p] = id
+	}
+	return out, nil
+}
+
+// commitParents picks the parent of a new commit for every project it touches:
+// the newest pending local commit for that project, or else the last known
+// remote head. Projects with neither start a new chain.
+func commitParents(paths []string) (map[string]string, error) {
+	commits, err := commit.List()
+	if err != nil {
+		return nil, err
+	}
+	heads, err := commit.LoadHeads()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := currentProjectIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]string{}
+	for _, p := range paths {
+		root := ids.rootOf(p)
+		if root == "" {
+			continue
+		}
+		if _, ok := out[root]; ok {
+			continue
+		}
+
+		// Remote heads are recorded per project ID.
+		parent := strings.TrimSpace(heads.Projects[ids.remoteID(root)])
+		// commit.List is oldest first; the last match wins.
+		for _, prev := range commits {
+			if strings.TrimSpace(prev.PushedAt) != "" {
+				continue
+			}
+			if commitTouchesRoot(ids, prev, root) {
+				parent = prev.ID
+			}
+		}
+		if parent != "" {
+			out[root] = parent
+		}
+	}
+	return out, nil
+}
+
+func commitTouchesRoot(ids *projectIDs, c commit.Commit, root string) bool {
+	for _, p := range c.Paths() {
+		if ids.rootOf(p) == root {
+			return true
+		}
+	}
+	return false
+}
+
+const commitUsage = "usage: sentra commit -m 'message' | sentra commit --amend [-m 'message']"
+
+// parseCommitArgs reads -m and --amend. The message is required unless
+// amending, where it replaces the old one only if given.
+func parseCommitArgs(args []string) (message string, amend bool, err error) {
+	hasMessage := false
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
 		case "-m":
 			if i+1 >= len(args) {
-				return "", errors.New("usage: sentra commit -m 'message'")
+				return "", false, errors.New(commitUsage)
 			}
 			message = args[i+1]
+			hasMessage = true
 			i++
+		case "--amend":
+			amend = true
 		default:
-			return "", errors.New("usage: sentra commit -m 'message'")
+			return "", false, errors.New(commitUsage)
 		}
 	}
+	if !hasMessage && !amend {
+		return "", false, errors.New(commitUsage)
+	}
 
 	message = strings.TrimSpace(message)
-	if message == "" {
-		return "", errors.New("commit message cannot be empty")
+	if hasMessage && message == "" {
+		return "", false, errors.New("commit message cannot be empty")
 	}
 
-	return message, nil
+	return message, amend, nil
 }
 
 func runPreCommitChecks() error {
@@ -23,6 +23,8 @@ type remoteCommit struct {
 	ProjectID   string   `json:"project_id"`
 	ProjectName string   `json:"project_name"`
 	FileCount   int      `json:"file_count"`
+	// ClientID is the local commit id on the machine that pushed it.
+	ClientID string `json:"client_id,omitempty"`
 }
 
 func runCommits(args []string) error {
@@ -30,8 +32,10 @@ func runCommits(args []string) error {
 		return errors.New("usage: sentra commits <project>")
 	}
 
-	root := projectRootFromPath(args[0])
-	root = strings.TrimSpace(root)
+	root, err := remoteProjectID(args[0])
+	if err != nil {
+		return err
+	}
 	if root == "" {
 		return errors.New("usage: sentra commits <project>")
 	}
@@ -0,0 +1,407 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/dotenv"
+	"github.com/mgeovany/sentra/cli/internal/index"
+	"github.com/mgeovany/sentra/cli/internal/objects"
+)
+
+const diffUsage = "usage: sentra diff [<project>[/<file>]] [--staged|--remote] [--show-values]"
+
+// maskedValue stands in for secret values in diffs. It is fixed-width so it
+// leaks nothing about the value, not even its length.
+const maskedValue = "••••••"
+
+type diffMode int
+
+const (
+	// diffWorking compares the working copy against the staged version, or
+	// the last commit when the file isn't staged.
+	diffWorking diffMode = iota
+	// diffStaged compares the staged version against the last commit.
+	diffStaged
+	// diffRemote compares the working copy against the remote head.
+	diffRemote
+)
+
+type keyChange struct {
+	Op  byte // '+', '-' or '~'
+	Key string
+	Old string
+	New string
+}
+
+// diffSide is one version of a file; ok is false if the file doesn't exist
+// in that version, and unavailable is set if it exists but its contents can't
+// be read (e.g. no local snapshot).
+type diffSide struct {
+	content     []byte
+	ok          bool
+	unavailable string
+}
+
+// sentra diff [<project>[/<file>]] [--staged|--remote] [--show-values]
+func runDiff(args []string) error {
+	target, mode, showValues, err := parseDiffArgs(args)
+	if err != nil {
+		return err
+	}
+
+	scanRoot, err := resolveScanRoot()
+	if err != nil {
+		return err
+	}
+	verbosef("Scan root: %s", scanRoot)
+
+	projects, err := scanProjects(scanRoot)
+	if err != nil {
+		return err
+	}
+	working := filterDiffPaths(flattenScan(scanRoot, projects), target)
+
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		return err
+	}
+	idx, _, err := index.Load(indexPath)
+	if err != nil {
+		return err
+	}
+	staged := filterDiffPaths(idx.Staged, target)
+
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+	lastCommitted := lastCommittedObjects(commits, target)
+
+	readWorking := func(p string) diffSide {
+		if _, ok := working[p]; !ok {
+			return diffSide{}
+		}
+		b, err := os.ReadFile(filepath.Join(scanRoot, filepath.FromSlash(p)))
+		if err != nil {
+			return diffSide{ok: true, unavailable: err.Error()}
+		}
+		return diffSide{content: b, ok: true}
+	}
+	readStaged := func(p string) diffSide {
+		if _, ok := staged[p]; !ok {
+			return diffSide{}
+		}
+		return readObject(idx.Objects[p], "staged before snapshots were kept; run: sentra add "+p)
+	}
+	readCommitted := func(p string) diffSide {
+		id, ok := lastCommitted[p]
+		if !ok {
+			return diffSide{}
+		}
+		return readObject(id, "committed before snapshots were kept")
+	}
+
+	var paths []string
+	var oldSide, newSide func(string) diffSide
+	var oldLabel, newLabel string
+	switch mode {
+	case diffStaged:
+		paths = sortedKeys(staged)
+		oldSide, oldLabel = readCommitted, "last commit"
+		newSide, newLabel = readStaged, "staged"
+	case diffRemote:
+		remote, err := fetchRemoteDiffFiles(scanRoot, working, target)
+		if err != nil {
+			return err
+		}
+		paths = unionKeys(sortedKeys(working), sortedKeys(remote))
+		oldSide = func(p string) diffSide {
+			b, ok := remote[p]
+			return diffSide{content: b, ok: ok}
+		}
+		oldLabel = "remote"
+		newSide, newLabel = readWorking, "working copy"
+	default:
+		paths = unionKeys(sortedKeys(working), sortedKeys(staged), sortedKeys(lastCommitted))
+		oldSide = func(p string) diffSide {
+			if _, ok := staged[p]; ok {
+				return readStaged(p)
+			}
+			return readCommitted(p)
+		}
+		oldLabel = "index"
+		newSide, newLabel = readWorking, "working copy"
+	}
+	verbosef("Comparing %s against %s for %d file(s)", newLabel, oldLabel, len(paths))
+
+	changedFiles := 0
+	var added, removed, changed int
+	for _, p := range paths {
+		o, n := oldSide(p), newSide(p)
+		if !o.ok && !n.ok {
+			continue
+		}
+		if o.ok && n.ok && o.unavailable == "" && n.unavailable == "" && string(o.content) == string(n.content) {
+			continue
+		}
+
+		changedFiles++
+		from := oldLabel
+		if mode == diffWorking {
+			from = "last commit"
+			if _, ok := staged[p]; ok {
+				from = "staged"
+			}
+		}
+		fmt.Println(c(ansiBold, p) + " " + c(ansiDim, "("+from+" → "+newLabel+")"))
+		switch {
+		case o.unavailable != "":
+			fmt.Println("  " + c(ansiYellow, "? cannot show changes: "+o.unavailable))
+			continue
+		case n.unavailable != "":
+			fmt.Println("  " + c(ansiYellow, "? cannot show changes: "+n.unavailable))
+			continue
+		case !o.ok:
+			fmt.Println("  " + c(ansiGreen, "new file"))
+		case !n.ok:
+			fmt.Println("  " + c(ansiRed, "deleted"))
+		}
+
+		changes := diffEnvKeys(o.content, n.content)
+		if len(changes) == 0 {
+			fmt.Println("  " + c(ansiDim, "formatting or comments only"))
+			continue
+		}
+		for _, ch := range changes {
+			fmt.Println("  " + formatKeyChange(ch, showValues))
+			switch ch.Op {
+			case '+':
+				added++
+			case '-':
+				removed++
+			default:
+				changed++
+			}
+		}
+	}
+
+	if changedFiles == 0 {
+		successf("✔ no changes")
+		return nil
+	}
+	fmt.Println()
+	infof("%d file(s) changed: %d added, %d removed, %d changed key(s)", changedFiles, added, removed, changed)
+	return nil
+}
+
+func parseDiffArgs(args []string) (target string, mode diffMode, showValues bool, err error) {
+	for _, a := range args {
+		switch a {
+		case "--staged", "--cached":
+			if mode != diffWorking {
+				return "", 0, false, errors.New(diffUsage)
+			}
+			mode = diffStaged
+		case "--remote":
+			if mode != diffWorking {
+				return "", 0, false, errors.New(diffUsage)
+			}
+			mode = diffRemote
+		case "--show-values":
+			showValues = true
+		default:
+			if strings.HasPrefix(a, "-") || target != "" {
+				return "", 0, false, errors.New(diffUsage)
+			}
+			target = normalizeRelPath(a)
+			if target == "." {
+				target = ""
+			}
+		}
+	}
+	return target, mode, showValues, nil
+}
+
+// diffEnvKeys compares two .env files key by key. Keys are reported in the
+// order they appear in b, followed by keys only present in a.
+func diffEnvKeys(a, b []byte) []keyChange {
+	oldFile, newFile := dotenv.Parse(a), dotenv.Parse(b)
+	oldVals, newVals := oldFile.Map(), newFile.Map()
+
+	var out []keyChange
+	for _, k := range newFile.Keys() {
+		ov, ok := oldVals[k]
+		switch {
+		case !ok:
+			out = append(out, keyChange{Op: '+', Key: k, New: newVals[k]})
+		case ov != newVals[k]:
+			out = append(out, keyChange{Op: '~', Key: k, Old: ov, New: newVals[k]})
+		}
+	}
+	for _, k := range oldFile.Keys() {
+		if _, ok := newVals[k]; !ok {
+			out = append(out, keyChange{Op: '-', Key: k, Old: oldVals[k]})
+		}
+	}
+	return out
+}
+
+func formatKeyChange(ch keyChange, showValues bool) string {
+	show := func(v string) string {
+		if !showValues {
+			return maskedValue
+		}
+		if strings.ContainsAny(v, "\r\n\t") {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	switch ch.Op {
+	case '+':
+		return c(ansiGreen, "+ "+ch.Key+"="+show(ch.New))
+	case '-':
+		return c(ansiRed, "- "+ch.Key+"="+show(ch.Old))
+	default:
+		if !showValues {
+			return c(ansiYellow, "~ "+ch.Key+" (value changed)")
+		}
+		return c(ansiYellow, "~ "+ch.Key+": "+show(ch.Old)+" → "+show(ch.New))
+	}
+}
+
+func readObject(id string, missing string) diffSide {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return diffSide{ok: true, unavailable: missing}
+	}
+	b, err := objects.Get(id)
+	if err != nil {
+		return diffSide{ok: true, unavailable: err.Error()}
+	}
+	return diffSide{content: b, ok: true}
+}
+
+// lastCommittedObjects returns, for each path under target, the snapshot id
+// from the newest local commit that contains it ("" for legacy commits).
+func lastCommittedObjects(commits []commit.Commit, target string) map[string]string {
+	out := map[string]string{}
+	// commit.List is oldest first; later commits overwrite earlier ones.
+	for _, cm := range commits {
+		for p := range cm.Files {
+			if !matchesDiffTarget(p, target) {
+				continue
+			}
+			id, _ := cm.ObjectID(p)
+			out[p] = id
+		}
+		for _, p := range cm.Deleted {
+			delete(out, p)
+		}
+	}
+	return out
+}
+
+// fetchRemoteDiffFiles downloads and decrypts the remote head of every
+// project in scope.
+func fetchRemoteDiffFiles(scanRoot string, working map[string]string, target string) (map[string][]byte, error) {
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(sess.AccessToken) == "" {
+		return nil, errors.New("not logged in (run: sentra login)")
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		return nil, err
+	}
+	roots := map[string]struct{}{}
+	if target != "" {
+		roots[ids.rootOf(target)] = struct{}{}
+	} else {
+		for p := range working {
+			roots[ids.rootOf(p)] = struct{}{}
+		}
+		projects, err := fetchRemoteProjects(serverURL, sess.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			if root, ok := ids.localRoot(strings.TrimSpace(p.RootPath)); ok {
+				roots[root] = struct{}{}
+			}
+		}
+	}
+
+	out := map[string][]byte{}
+	sp := startSpinner("Fetching remote files...")
+	for _, root := range sortedKeys(roots) {
+		id := ids.remoteID(root)
+		sp.Set(fmt.Sprintf("Fetching %s...", root))
+		files, err := fetchRemoteExport(serverURL, sess.AccessToken, id)
+		if err != nil {
+			sp.StopInfo("")
+			return nil, err
+		}
+		for _, f := range files {
+			// Remote paths are under the project id; compare them as local paths.
+			p, ok := localPath(id, root, strings.TrimSpace(f.Path))
+			if !ok || f.Deleted || !matchesDiffTarget(p, target) {
+				continue
+			}
+			plain, err := decryptRemoteExportFile(id, f)
+			if err != nil {
+				sp.StopInfo("")
+				return nil, err
+			}
+			out[p] = plain
+		}
+	}
+	sp.StopInfo("")
+	return out, nil
+}
+
+func matchesDiffTarget(p string, target string) bool {
+	return target == "" || p == target || strings.HasPrefix(p, target+"/")
+}
+
+func filterDiffPaths[V any](m map[string]V, target string) map[string]V {
+	out := make(map[string]V, len(m))
+	for p, v := range m {
+		if matchesDiffTarget(p, target) {
+			out[p] = v
+		}
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func unionKeys(lists ...[]string) []string {
+	set := map[string]struct{}{}
+	for _, l := range lists {
+		for _, k := range l {
+			set[k] = struct{}{}
+		}
+	}
+	return sortedKeys(set)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/mgeovany/sentra/cli/internal/auth"
 	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/objects"
 	"github.com/zalando/go-keyring"
 )
 
@@ -66,6 +67,12 @@ func runDoctor() error {
 		d.warnf("missing machine id in auth config")
 	} else {
 		d.okf("machine id configured")
+		if cfg.RecoveryExportedAt == nil {
+			d.warnf("%s", c(ansiRed, "NO RECOVERY KIT EXPORTED: if this machine is lost, every pushed env file is unrecoverable"))
+			d.warnf("fix: sentra key export")
+		} else {
+			d.okf("recovery kit exported %s", cfg.RecoveryExportedAt.Local().Format("2006-01-02"))
+		}
 	}
 
 	sess, sessOK, err := auth.LoadSession()
@@ -204,6 +211,14 @@ func runDoctor() error {
 				d.okf("commits dir: %s", commitsDir)
 			}
 		}
+
+		if objectsDir, err := objects.Dir(); err == nil {
+			if _, err := os.Stat(objectsDir); err == nil {
+				d.okf("objects dir: %s", objectsDir)
+			} else if !os.IsNotExist(err) {
+				d.warnf("cannot stat objects dir: %v", err)
+			}
+		}
 	}
 
 	// Best-effort keychain diagnostics (no writes).
@@ -2,7 +2,6 @@ package cli
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,9 +13,6 @@ import (
 	"sort"
 	"strings"
 	"time"
-
-	"github.com/mgeovany/sentra/cli/internal/auth"
-	"github.com/mgeovany/sentra/cli/internal/storage"
 )
 
 type remoteExportFile struct {
@@ -31,15 +27,26 @@ type remoteExportFile struct {
 	StorageKey      string `json:"storage_key"`
 	StorageEndpoint string `json:"storage_endpoint"`
 	StorageRegion   string `json:"storage_region"`
+	// Deleted marks a tombstone: the file was removed at this commit and
+	// carries no content.
+	Deleted bool `json:"deleted,omitempty"`
 }
 
 func runExport(args []string) error {
 	verbosef("Starting export operation...")
-	root, at, err := parseExportArgs(args)
+	project, at, err := parseExportArgs(args)
+	if err != nil {
+		return err
+	}
+	ids, err := currentProjectIDs()
 	if err != nil {
 		return err
 	}
-	verbosef("Project root: %s", root)
+	id, root := ids.resolveProject(project)
+	if root == "" {
+		root = id
+	}
+	verbosef("Project: %s (id %s)", root, id)
 	if at != "" {
 		verbosef("Exporting at commit: %s", at)
 	} else {
@@ -66,7 +73,7 @@ func runExport(args []string) error {
 		return err
 	}
 	q := u.Query()
-	q.Set("root", root)
+	q.Set("root", id)
 	if at != "" {
 		q.Set("at", at)
 	}
@@ -107,61 +114,24 @@ func runExport(args []string) error {
 
 	baseDir := filepath.Join("sentra-export", root)
 	verbosef("Export directory: %s", baseDir)
-	if err := os.MkdirAll(baseDir, 0o755); err != nil {
-		return err
-	}
 
-	written := 0
+	// Decrypt and verify everything before writing anything.
+	type pendingWrite struct {
+		outPath string
+		plain   []byte
+	}
+	writes := make([]pendingWrite, 0, len(files))
+	skippedDeleted := 0
 	for i, f := range files {
-		verbosef("Processing file %d/%d: %s (size: %d bytes, cipher: %s)", i+1, len(files), f.Path, f.Size, f.Cipher)
-		cipherName := strings.TrimSpace(f.Cipher)
-		blobB64 := strings.TrimSpace(f.BlobB64)
-		if blobB64 == "" && strings.TrimSpace(f.StorageKey) != "" {
-			verbosef("File stored in BYOS, downloading from storage: %s", f.StorageKey)
-			s3cfg, _, enabled, err := storage.ResolveS3()
-			if err != nil {
-				return err
-			}
-			if !enabled {
-				return fmt.Errorf("export requires storage setup (run: sentra storage setup)")
-			}
-			// Prefer server-provided location if present.
-			if strings.TrimSpace(f.StorageBucket) != "" {
-				s3cfg.Bucket = strings.TrimSpace(f.StorageBucket)
-				verbosef("Using storage bucket: %s", s3cfg.Bucket)
-			}
-			if strings.TrimSpace(f.StorageEndpoint) != "" {
-				s3cfg.Endpoint = strings.TrimSpace(f.StorageEndpoint)
-				verbosef("Using storage endpoint: %s", s3cfg.Endpoint)
-			}
-			if strings.TrimSpace(f.StorageRegion) != "" {
-				s3cfg.Region = strings.TrimSpace(f.StorageRegion)
-				verbosef("Using storage region: %s", s3cfg.Region)
-			}
-			// Recreate S3 client after applying server-provided overrides
-			// since MinIO clients are bound to endpoint/region at construction.
-			s3c, err := storage.NewS3Client(s3cfg)
-			if err != nil {
-				return fmt.Errorf("failed to connect to storage (%s)", f.Path)
-			}
-			verbosef("Downloading from storage: %s", f.StorageKey)
-			raw, err := storage.GetObject(context.Background(), s3c, s3cfg, strings.TrimSpace(f.StorageKey))
-			if err != nil {
-				return fmt.Errorf("failed to download from storage (%s)", f.Path)
-			}
-			verbosef("Downloaded %d bytes from storage", len(raw))
-			blobB64 = base64.RawURLEncoding.EncodeToString(raw)
+		if f.Deleted {
+			verbosef("Skipping %s: deleted at this commit", f.Path)
+			skippedDeleted++
+			continue
 		}
-
-		verbosef("Decrypting file: %s", f.Path)
-		plain, err := auth.DecryptEnvBlob(cipherName, blobB64)
-		if err != nil {
-			return fmt.Errorf("failed to decrypt file (%s)", f.Path)
-		}
-		verbosef("Decrypted file: %s (%d bytes)", f.Path, len(plain))
+		verbosef("Processing file %d/%d: %s (size: %d bytes, cipher: %s)", i+1, len(files), f.Path, f.Size, f.Cipher)
 
 		rel := strings.TrimSpace(f.Path)
-		rel = strings.TrimPrefix(rel, root+"/")
+		rel = strings.TrimPrefix(rel, id+"/")
 		rel = filepath.ToSlash(rel)
 		rel = strings.TrimPrefix(rel, "/")
 		rel = filepath.Clean(rel)
@@ -173,36 +143,51 @@ func runExport(args []string) error {
 			return fmt.Errorf("invalid file path received from server")
 		}
 
-		outPath := filepath.Join(baseDir, filepath.FromSlash(rel))
-		verbosef("Writing file to: %s", outPath)
-		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		verbosef("Decrypting file: %s", f.Path)
+		plain, err := decryptRemoteExportFile(id, f)
+		if err != nil {
+			return err
+		}
+		verbosef("Decrypted and verified file: %s (%d bytes)", f.Path, len(plain))
+		writes = append(writes, pendingWrite{outPath: filepath.Join(baseDir, filepath.FromSlash(rel)), plain: plain})
+	}
+
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return err
+	}
+	written := 0
+	for _, w := range writes {
+		verbosef("Writing file to: %s", w.outPath)
+		if err := os.MkdirAll(filepath.Dir(w.outPath), 0o755); err != nil {
 			return err
 		}
-		if err := os.WriteFile(outPath, plain, 0o600); err != nil {
+		if err := os.WriteFile(w.outPath, w.plain, 0o600); err != nil {
 			return err
 		}
 		written++
-		verbosef("Successfully exported file: %s", outPath)
+		verbosef("Successfully exported file: %s", w.outPath)
 	}
 
 	fmt.Printf("✔ exported %d files to %s\n", written, baseDir)
+	if skippedDeleted > 0 {
+		infof("%d deleted file(s) skipped", skippedDeleted)
+	}
 	verbosef("Export completed: %d file(s) written to %s", written, baseDir)
 	return nil
 }
 
-func parseExportArgs(args []string) (root string, at string, err error) {
+func parseExportArgs(args []string) (project string, at string, err error) {
 	if len(args) < 1 {
 		return "", "", errors.New("usage: sentra export <project> [--at <commit>]")
 	}
 
-	root = projectRootFromPath(args[0])
-	root = strings.TrimSpace(root)
-	if root == "" {
+	project = strings.Trim(strings.TrimPrefix(strings.TrimSpace(args[0]), "./"), "/")
+	if project == "" {
 		return "", "", errors.New("usage: sentra export <project> [--at <commit>]")
 	}
 
 	if len(args) == 1 {
-		return root, "", nil
+		return project, "", nil
 	}
 	if len(args) != 3 || args[1] != "--at" {
 		return "", "", errors.New("usage: sentra export <project> [--at <commit>]")
@@ -211,5 +196,5 @@ func parseExportArgs(args []string) (root string, at string, err error) {
 	if at == "" {
 		return "", "", errors.New("usage: sentra export <project> [--at <commit>]")
 	}
-	return root, at, nil
+	return project, at, nil
 }
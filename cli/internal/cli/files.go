@@ -91,8 +91,10 @@ func parseFilesArgs(args []string) (root string, at string, err error) {
 		return "", "", errors.New("usage: sentra files <project> [--at <commit>]")
 	}
 
-	root = projectRootFromPath(args[0])
-	root = strings.TrimSpace(root)
+	root, err = remoteProjectID(args[0])
+	if err != nil {
+		return "", "", err
+	}
 	if root == "" {
 		return "", "", errors.New("usage: sentra files <project> [--at <commit>]")
 	}
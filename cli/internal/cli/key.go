@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+	"golang.org/x/term"
+)
+
+const defaultRecoveryKitFile = "sentra-recovery-kit.txt"
+
+func runKey(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: sentra key export [--out <file>|--print] | sentra key import [<file>] | sentra key identity [--out <file>]")
+	}
+	switch args[0] {
+	case "export":
+		return runKeyExport(args[1:])
+	case "import":
+		return runKeyImport(args[1:])
+	case "identity":
+		return runKeyIdentity(args[1:])
+	default:
+		return errors.New("usage: sentra key export [--out <file>|--print] | sentra key import [<file>] | sentra key identity [--out <file>]")
+	}
+}
+
+// runKeyExport writes a passphrase-protected recovery kit holding the keys
+// needed to decrypt everything this machine can read.
+func runKeyExport(args []string) error {
+	out := ""
+	printCode := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--out", "-o":
+			if i+1 >= len(args) || strings.TrimSpace(args[i+1]) == "" {
+				return errors.New("usage: sentra key export [--out <file>|--print]")
+			}
+			out = strings.TrimSpace(args[i+1])
+			i++
+		case "--print":
+			printCode = true
+		default:
+			return errors.New("usage: sentra key export [--out <file>|--print]")
+		}
+	}
+	if printCode && out != "" {
+		return errors.New("use either --out or --print")
+	}
+	if !printCode && out == "" {
+		out = defaultRecoveryKitFile
+	}
+	if out != "" {
+		if _, err := os.Stat(out); err == nil {
+			return fmt.Errorf("%s already exists; refusing to overwrite", out)
+		}
+	}
+
+	pass, err := readPassphrase("Recovery passphrase: ")
+	if err != nil {
+		return err
+	}
+	if len(pass) < auth.MinRecoveryPassphraseLen {
+		return fmt.Errorf("passphrase must be at least %d characters", auth.MinRecoveryPassphraseLen)
+	}
+	confirm, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return err
+	}
+	if pass != confirm {
+		return errors.New("passphrases do not match")
+	}
+
+	sp := startSpinner("Deriving key...")
+	kit, err := auth.ExportRecoveryKit(pass)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	sp.StopInfo("")
+
+	cfg, err := auth.EnsureConfig()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+
+	if printCode {
+		fmt.Println("Recovery code (write it down; import with: sentra key import):")
+		fmt.Println()
+		fmt.Println(auth.RecoveryCode(kit))
+		fmt.Println()
+	} else {
+		f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(auth.RecoveryKitArmor(kit, cfg.MachineID, now)); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		successf("✔ recovery kit written to %s", out)
+	}
+
+	if err := auth.MarkRecoveryExported(now); err != nil {
+		return err
+	}
+	warnf("Store it somewhere off this machine. Anyone with the kit and passphrase can decrypt your env files.")
+	return nil
+}
+
+// runKeyImport restores keys from a recovery kit file or a pasted recovery code.
+func runKeyImport(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: sentra key import [<file>]")
+	}
+
+	var data []byte
+	if len(args) == 1 && strings.TrimSpace(args[0]) != "-" {
+		b, err := os.ReadFile(strings.TrimSpace(args[0]))
+		if err != nil {
+			return err
+		}
+		data = b
+	} else {
+		fmt.Println("Paste the recovery code, then an empty line:")
+		b, err := readUntilBlankLine(os.Stdin)
+		if err != nil {
+			return err
+		}
+		data = b
+	}
+
+	pass, err := readPassphrase("Recovery passphrase: ")
+	if err != nil {
+		return err
+	}
+
+	sp := startSpinner("Deriving key...")
+	keys, err := auth.ImportRecoveryKit(data, pass)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	sp.StopSuccess(fmt.Sprintf("✔ restored %d key(s)", len(keys.SessionKeys)+len(keys.Identities)))
+	fmt.Println("Run: sentra sync")
+	return nil
+}
+
+// runKeyIdentity prints (or writes) this machine's identities in age format,
+// so age-v1 blobs can be decrypted with the standard `age` tool.
+func runKeyIdentity(args []string) error {
+	out := ""
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && (args[0] == "--out" || args[0] == "-o") && strings.TrimSpace(args[1]) != "":
+		out = strings.TrimSpace(args[1])
+	default:
+		return errors.New("usage: sentra key identity [--out <file>]")
+	}
+
+	b, err := auth.AgeIdentityFile()
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		fmt.Print(string(b))
+		return nil
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists; refusing to overwrite", out)
+		}
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	successf("✔ age identity written to %s", out)
+	warnf("This file decrypts your env files; delete it when done.")
+	return nil
+}
+
+func readPassphrase(prompt string) (string, error) {
+	if v := os.Getenv("SENTRA_RECOVERY_PASSPHRASE"); v != "" {
+		return v, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("passphrase prompt requires a TTY (or set SENTRA_RECOVERY_PASSPHRASE)")
+	}
+	fmt.Print(prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func readUntilBlankLine(r io.Reader) ([]byte, error) {
+	var out []byte
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			if len(out) > 0 {
+				break
+			}
+			continue
+		}
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+)
+
+const (
+	linkPollInterval = 3 * time.Second
+	linkWaitTimeout  = 15 * time.Minute
+)
+
+type createLinkRequest struct {
+	MachineName string `json:"machine_name"`
+	EncPubKey   string `json:"enc_pub_key"`
+}
+
+type linkBundleResponse struct {
+	Status     string `json:"status"`
+	ApprovedBy string `json:"approved_by"`
+	Bundle     string `json:"bundle"`
+}
+
+// runLink asks an already-trusted machine for the account's key material.
+// The other machine approves with `sentra machines approve`.
+func runLink(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: sentra link")
+	}
+
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(sess.AccessToken) == "" {
+		return errors.New("not logged in (run: sentra login)")
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+	cfg, err := auth.EnsureConfig()
+	if err != nil {
+		return err
+	}
+
+	// The link endpoints are device-signed, so the machine must be registered.
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := registerMachine(ctx, sess.AccessToken)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	encPub, err := auth.GetOrCreateDeviceEncryptionPublicKey()
+	if err != nil {
+		return err
+	}
+	devicePub, err := auth.GetOrCreateDevicePublicKey()
+	if err != nil {
+		return err
+	}
+	name, _ := os.Hostname()
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "unknown"
+	}
+
+	b, err := json.Marshal(createLinkRequest{MachineName: name, EncPubKey: encPub})
+	if err != nil {
+		return err
+	}
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		status, body, err := doSignedRequest(ctx, serverURL, sess.AccessToken, cfg.MachineID, http.MethodPost, "/machines/link", b)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if status < 200 || status >= 300 {
+			return fmt.Errorf("link request failed: server returned %d (%s)", status, oneLine(string(body)))
+		}
+	}
+
+	fmt.Printf("Link code: %s\n", c(ansiBoldCyan, auth.LinkCode(devicePub, encPub)))
+	fmt.Println("On a machine that's already set up, run: sentra machines approve")
+	fmt.Println("and check that it shows the same code.")
+	fmt.Println()
+
+	sp := startSpinner("Waiting for approval...")
+	deadline := time.Now().Add(linkWaitTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		status, body, err := doSignedRequest(ctx, serverURL, sess.AccessToken, cfg.MachineID, http.MethodGet, "/machines/link/bundle", nil)
+		cancel()
+		if err != nil {
+			sp.StopInfo("")
+			return err
+		}
+		if status == http.StatusNotFound {
+			sp.StopInfo("")
+			return errors.New("link request expired or was removed (run: sentra link again)")
+		}
+		if status < 200 || status >= 300 {
+			sp.StopInfo("")
+			return fmt.Errorf("link status failed: server returned %d (%s)", status, oneLine(string(body)))
+		}
+
+		var res linkBundleResponse
+		if err := json.Unmarshal(body, &res); err != nil {
+			sp.StopInfo("")
+			return err
+		}
+		if res.Status == "approved" && strings.TrimSpace(res.Bundle) != "" {
+			keys, err := auth.OpenLinkBundle(res.Bundle)
+			if err != nil {
+				sp.StopInfo("")
+				return err
+			}
+			sp.StopSuccess(fmt.Sprintf("✔ linked (approved by %s)", res.ApprovedBy))
+			verbosef("Received %d legacy key(s), %d machine key(s)", len(keys.SessionKeys), len(keys.Identities))
+			infof("Trusting %s and the machines it approved (%d in all): pushes from this machine are encrypted to them. To add others: sentra machines trust <machine-id>", res.ApprovedBy, len(keys.Trusted))
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			sp.StopInfo("")
+			return errors.New("timed out waiting for approval (run: sentra link again)")
+		}
+		time.Sleep(linkPollInterval)
+	}
+}
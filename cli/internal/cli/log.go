@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mgeovany/sentra/cli/internal/commit"
 	"github.com/mgeovany/sentra/cli/internal/index"
+	"github.com/mgeovany/sentra/cli/internal/objects"
 )
 
 func runLog(args []string) error {
@@ -51,13 +53,15 @@ func runLog(args []string) error {
 			return errors.New("usage: sentra log prune <id|all>")
 		}
 		return runLogPrune(strings.TrimSpace(args[1]))
+	case "squash":
+		return runLogSquash(args[1:])
 	case "verify":
 		if len(args) != 1 {
 			return errors.New("usage: sentra log verify")
 		}
 		return runLogVerify()
 	default:
-		return errors.New("usage: sentra log [all|pending|pushed|rm <id>|clear|prune <id|all>|verify]")
+		return errors.New("usage: sentra log [all|pending|pushed|rm <id>|clear|prune <id|all>|squash <id>..[<id>]|verify]")
 	}
 }
 
@@ -125,6 +129,9 @@ func runLogList(mode string) error {
 			fmt.Println(c(ansiDim, "Pushed: ") + strings.TrimSpace(cm.PushedAt))
 		}
 		fmt.Println(c(ansiDim, "Files: ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(cm.Files))))
+		if len(cm.Deleted) > 0 {
+			fmt.Println(c(ansiDim, "Deleted: ") + c(ansiBoldCyan, strings.Join(cm.Deleted, ", ")))
+		}
 
 		msg := strings.TrimSpace(cm.Message)
 		if msg == "" {
@@ -195,13 +202,13 @@ func runLogVerify() error {
 		if strings.TrimSpace(c.PushedAt) != "" {
 			continue
 		}
-		missing := missingFilesForCommit(scanRoot, c)
-		if len(missing) == 0 {
+		unreadable := unreadableFilesForCommit(scanRoot, c)
+		if len(unreadable) == 0 {
 			continue
 		}
 		issues++
-		fmt.Printf("commit %s missing %d file(s):\n", c.ID, len(missing))
-		for _, p := range missing {
+		fmt.Printf("commit %s has %d unreadable file(s):\n", c.ID, len(unreadable))
+		for _, p := range unreadable {
 			fmt.Printf("  %s\n", p)
 		}
 		fmt.Printf("  fix: sentra log prune %s\n\n", c.ID)
@@ -211,7 +218,7 @@ func runLogVerify() error {
 		fmt.Println("✔ all pending commits are readable")
 		return nil
 	}
-	return errors.New("missing files detected in pending commits")
+	return errors.New("unreadable files detected in pending commits")
 }
 
 func runLogPrune(selector string) error {
@@ -269,11 +276,12 @@ func runLogPrune(selector string) error {
 		}
 		for _, p := range missing {
 			delete(c.Files, p)
+			delete(c.Objects, p)
 		}
 		prunedFiles += len(missing)
 		prunedCommits++
 
-		if len(c.Files) == 0 {
+		if len(c.Files) == 0 && len(c.Deleted) == 0 {
 			if err := commit.Delete(c.ID); err != nil {
 				return err
 			}
@@ -320,9 +328,18 @@ func resolveScanRootFromIndex() (string, error) {
 	return defaultRoot, nil
 }
 
+// missingFilesForCommit lists paths whose content can no longer be found:
+// the snapshot is gone from the object store, or (for legacy commits) the
+// working copy file was deleted.
 func missingFilesForCommit(scanRoot string, c commit.Commit) []string {
 	var missing []string
 	for p := range c.Files {
+		if id, ok := c.ObjectID(p); ok {
+			if !objects.Has(id) {
+				missing = append(missing, p)
+			}
+			continue
+		}
 		abs := filepath.Join(scanRoot, filepath.FromSlash(p))
 		if _, err := os.Stat(abs); err != nil {
 			if os.IsNotExist(err) {
@@ -333,6 +350,29 @@ func missingFilesForCommit(scanRoot string, c commit.Commit) []string {
 	return missing
 }
 
+// unreadableFilesForCommit is stricter than missingFilesForCommit: it reads
+// every file the way push would, so corrupt or undecryptable snapshots are
+// reported too.
+func unreadableFilesForCommit(scanRoot string, c commit.Commit) []string {
+	paths := make([]string, 0, len(c.Files))
+	for p := range c.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var out []string
+	for _, p := range paths {
+		if _, err := commitFileContents(scanRoot, c, p); err != nil {
+			verbosef("%s: %v", p, err)
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// errCommitNotFound is returned by resolveCommitID when nothing matches.
+var errCommitNotFound = errors.New("commit not found")
+
 func resolveCommitID(commits []commit.Commit, selector string) (string, error) {
 	selector = strings.TrimSpace(selector)
 	if selector == "" {
@@ -344,7 +384,7 @@ func resolveCommitID(commits []commit.Commit, selector string) (string, error) {
 				return c.ID, nil
 			}
 		}
-		return "", fmt.Errorf("commit not found: %s", selector)
+		return "", fmt.Errorf("%w: %s", errCommitNotFound, selector)
 	}
 
 	var matches []commit.Commit
@@ -360,7 +400,7 @@ func resolveCommitID(commits []commit.Commit, selector string) (string, error) {
 	}
 
 	if len(matches) == 0 {
-		return "", fmt.Errorf("commit not found: %s", selector)
+		return "", fmt.Errorf("%w: %s", errCommitNotFound, selector)
 	}
 	if len(matches) > 1 {
 		return "", fmt.Errorf("commit selector is ambiguous: %s", selector)
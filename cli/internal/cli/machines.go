@@ -0,0 +1,396 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mgeovany/sentra/cli/internal/auth"
+)
+
+type remoteMachine struct {
+	MachineID    string `json:"machine_id"`
+	MachineName  string `json:"machine_name"`
+	DevicePubKey string `json:"device_pub_key"`
+	EncPubKey    string `json:"enc_pub_key"`
+	// EncPubKeySig is the machine's device-key signature over EncPubKey.
+	EncPubKeySig string `json:"enc_pub_key_sig"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// machineTrust is whether push may wrap content keys to a registered machine.
+type machineTrust int
+
+const (
+	// machineUntrusted: not approved on this machine.
+	machineUntrusted machineTrust = iota
+	machineTrusted
+	// machineKeysChanged: approved, but the server now reports a different
+	// device key, or an encryption key the pinned device key didn't sign.
+	machineKeysChanged
+)
+
+func checkMachineTrust(m remoteMachine, trusted auth.TrustedMachines) machineTrust {
+	t, ok := trusted.Machines[strings.TrimSpace(m.MachineID)]
+	if !ok {
+		return machineUntrusted
+	}
+	if strings.TrimSpace(m.DevicePubKey) != t.DevicePubKey {
+		return machineKeysChanged
+	}
+	if auth.VerifyEncPubKey(t.DevicePubKey, m.MachineID, m.EncPubKey, m.EncPubKeySig) != nil {
+		return machineKeysChanged
+	}
+	return machineTrusted
+}
+
+func fetchRemoteMachines(ctx context.Context, serverURL string, accessToken string) ([]remoteMachine, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(serverURL), "/") + "/machines"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(accessToken))
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch machines")
+	}
+
+	var machines []remoteMachine
+	if err := json.Unmarshal(body, &machines); err != nil {
+		return nil, err
+	}
+	return machines, nil
+}
+
+// fetchEncryptionRecipients returns this machine and every registered
+// machine approved on it (see checkMachineTrust). The server's machine list
+// alone is never trusted: a machine it reports that wasn't approved here is
+// left out with a warning, and one whose keys changed since it was approved
+// stops the push.
+func fetchEncryptionRecipients(ctx context.Context, serverURL string, accessToken string, machineID string) ([]auth.Recipient, error) {
+	machines, err := fetchRemoteMachines(ctx, serverURL, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := auth.LoadTrustedMachines()
+	if err != nil {
+		return nil, err
+	}
+
+	selfPub, err := auth.GetOrCreateDeviceEncryptionPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []auth.Recipient{{MachineID: machineID, PublicKey: selfPub}}
+	var skipped []remoteMachine
+	for _, m := range machines {
+		id := strings.TrimSpace(m.MachineID)
+		pub := strings.TrimSpace(m.EncPubKey)
+		if id == "" || pub == "" || id == machineID {
+			continue
+		}
+		switch checkMachineTrust(m, trusted) {
+		case machineTrusted:
+			out = append(out, auth.Recipient{MachineID: id, PublicKey: pub})
+		case machineKeysChanged:
+			return nil, fmt.Errorf("refusing to push: the server's keys for machine %s (%s) are not the ones approved here. If it was reinstalled, approve it again: sentra machines trust %s", m.MachineName, id, id)
+		default:
+			skipped = append(skipped, m)
+		}
+	}
+	if len(skipped) > 0 {
+		warnf("⚠ %d machine(s) not approved on this one won't be able to decrypt this push:", len(skipped))
+		for _, m := range skipped {
+			fmt.Printf("  %s (%s)  run: sentra machines trust %s\n", m.MachineName, m.MachineID, m.MachineID)
+		}
+	}
+	return out, nil
+}
+
+type remoteLinkRequest struct {
+	MachineID   string `json:"machine_id"`
+	MachineName string `json:"machine_name"`
+	EncPubKey   string `json:"enc_pub_key"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type approveLinkRequest struct {
+	MachineID string `json:"machine_id"`
+	Bundle    string `json:"bundle"`
+}
+
+func runMachines(args []string) error {
+	if len(args) == 0 {
+		return runMachinesList()
+	}
+	switch args[0] {
+	case "approve":
+		if len(args) > 2 {
+			return errors.New("usage: sentra machines approve [<machine-id>]")
+		}
+		only := ""
+		if len(args) == 2 {
+			only = strings.TrimSpace(args[1])
+		}
+		return runMachinesApprove(only)
+	case "trust":
+		if len(args) != 2 {
+			return errors.New("usage: sentra machines trust <machine-id>")
+		}
+		return runMachinesTrust(strings.TrimSpace(args[1]))
+	default:
+		return errors.New("usage: sentra machines [approve [<machine-id>] | trust <machine-id>]")
+	}
+}
+
+func runMachinesList() error {
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+	cfg, _, _ := auth.LoadConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	machines, err := fetchRemoteMachines(ctx, serverURL, sess.AccessToken)
+	if err != nil {
+		return err
+	}
+	if len(machines) == 0 {
+		fmt.Println("✔ 0 machines")
+		return nil
+	}
+	trusted, err := auth.LoadTrustedMachines()
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		line := fmt.Sprintf("%s  %s", m.MachineID, m.MachineName)
+		switch {
+		case m.MachineID == cfg.MachineID:
+			// Other machines compare this code when they trust this one.
+			line += c(ansiDim, "  (this machine, code "+auth.LinkCode(m.DevicePubKey, m.EncPubKey)+")")
+		case strings.TrimSpace(m.EncPubKey) == "":
+			line += c(ansiYellow, "  (no encryption key; run sentra login on it)")
+		default:
+			switch checkMachineTrust(m, trusted) {
+			case machineTrusted:
+				line += c(ansiGreen, "  (approved)")
+			case machineKeysChanged:
+				line += c(ansiRed, "  (keys changed since approved; run: sentra machines trust "+m.MachineID+")")
+			default:
+				line += c(ansiYellow, "  (not approved; run: sentra machines trust "+m.MachineID+")")
+			}
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runMachinesTrust approves a registered machine as a push recipient after
+// the user checks its code against the one `sentra machines` prints on it.
+func runMachinesTrust(machineID string) error {
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	machines, err := fetchRemoteMachines(ctx, serverURL, sess.AccessToken)
+	if err != nil {
+		return err
+	}
+	var m remoteMachine
+	found := false
+	for _, cand := range machines {
+		if cand.MachineID == machineID {
+			m, found = cand, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no machine %s on your account (run: sentra machines)", machineID)
+	}
+	if strings.TrimSpace(m.EncPubKey) == "" {
+		return fmt.Errorf("%s has no encryption key yet (run: sentra login on it)", m.MachineName)
+	}
+	if err := auth.VerifyEncPubKey(m.DevicePubKey, m.MachineID, m.EncPubKey, m.EncPubKeySig); err != nil {
+		return fmt.Errorf("cannot trust %s: %v (run: sentra login on it to re-register its keys)", m.MachineName, err)
+	}
+
+	fmt.Printf("%s %s (%s)\n", c(ansiBold, "Machine:"), m.MachineName, m.MachineID)
+	fmt.Printf("  code: %s\n", c(ansiBoldCyan, auth.LinkCode(m.DevicePubKey, m.EncPubKey)))
+	fmt.Print("Trust it if `sentra machines` on that machine shows the same code [y/N]: ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if ans := strings.ToLower(strings.TrimSpace(line)); ans != "y" && ans != "yes" {
+		infof("not trusted: %s", m.MachineName)
+		return nil
+	}
+	if err := auth.TrustMachine(m.MachineID, m.MachineName, m.DevicePubKey); err != nil {
+		return err
+	}
+	successf("✔ %s will receive keys to your next pushes", m.MachineName)
+	return nil
+}
+
+// runMachinesApprove lists pending `sentra link` requests and, after the user
+// confirms the link code, uploads this machine's key material sealed to the
+// requesting machine.
+func runMachinesApprove(only string) error {
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+	cfg, err := auth.EnsureConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	status, body, err := doSignedRequest(ctx, serverURL, sess.AccessToken, cfg.MachineID, http.MethodGet, "/machines/link", nil)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("failed to list link requests: server returned %d (%s)", status, oneLine(string(body)))
+	}
+	var pending []remoteLinkRequest
+	if err := json.Unmarshal(body, &pending); err != nil {
+		return err
+	}
+	if only != "" {
+		filtered := pending[:0]
+		for _, p := range pending {
+			if p.MachineID == only {
+				filtered = append(filtered, p)
+			}
+		}
+		pending = filtered
+	}
+	if len(pending) == 0 {
+		fmt.Println("✔ no pending link requests")
+		return nil
+	}
+
+	// The code covers the requesting machine's registered device key too, so
+	// approving it pins that key as a push recipient.
+	machines, err := fetchRemoteMachines(ctx, serverURL, sess.AccessToken)
+	if err != nil {
+		return err
+	}
+	registered := make(map[string]remoteMachine, len(machines))
+	for _, m := range machines {
+		registered[m.MachineID] = m
+	}
+
+	r := bufio.NewReader(os.Stdin)
+	for _, p := range pending {
+		m, ok := registered[p.MachineID]
+		if !ok || strings.TrimSpace(m.EncPubKey) != strings.TrimSpace(p.EncPubKey) {
+			warnf("⚠ skipped %s (%s): its link request doesn't match its registered keys", p.MachineName, p.MachineID)
+			continue
+		}
+		if err := auth.VerifyEncPubKey(m.DevicePubKey, m.MachineID, m.EncPubKey, m.EncPubKeySig); err != nil {
+			warnf("⚠ skipped %s (%s): %v", p.MachineName, p.MachineID, err)
+			continue
+		}
+		fmt.Printf("%s %s (%s)\n", c(ansiBold, "Link request:"), p.MachineName, p.MachineID)
+		fmt.Printf("  code: %s\n", c(ansiBoldCyan, auth.LinkCode(m.DevicePubKey, p.EncPubKey)))
+		fmt.Print("Approve if this matches the code shown on the new machine [y/N]: ")
+		line, _ := r.ReadString('\n')
+		if ans := strings.ToLower(strings.TrimSpace(line)); ans != "y" && ans != "yes" {
+			infof("skipped %s", p.MachineName)
+			continue
+		}
+		if err := auth.TrustMachine(p.MachineID, p.MachineName, m.DevicePubKey); err != nil {
+			return err
+		}
+
+		bundle, err := auth.SealLinkBundle(p.MachineID, p.EncPubKey)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(approveLinkRequest{MachineID: p.MachineID, Bundle: bundle})
+		if err != nil {
+			return err
+		}
+		status, body, err := doSignedRequest(ctx, serverURL, sess.AccessToken, cfg.MachineID, http.MethodPost, "/machines/link/approve", b)
+		if err != nil {
+			return err
+		}
+		if status < 200 || status >= 300 {
+			return fmt.Errorf("approve failed: server returned %d (%s)", status, oneLine(string(body)))
+		}
+		successf("✔ approved %s", p.MachineName)
+	}
+	return nil
+}
+
+// doSignedRequest sends a device-signed request (same scheme as /push).
+func doSignedRequest(ctx context.Context, serverURL, accessToken, machineID, method, path string, body []byte) (int, []byte, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(serverURL), "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(accessToken))
+
+	ts := fmt.Sprintf("%d", time.Now().UTC().Unix())
+	nonce := uuid.NewString()
+	sig, err := auth.SignDeviceRequest(machineID, ts, nonce, method, path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("X-Sentra-Machine-ID", machineID)
+	req.Header.Set("X-Sentra-Timestamp", ts)
+	req.Header.Set("X-Sentra-Nonce", nonce)
+	req.Header.Set("X-Sentra-Signature", sig)
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, nil
+}
@@ -20,6 +20,11 @@ type registerMachineRequest struct {
 	MachineName   string `json:"machine_name"`
 	DevicePubKey  string `json:"device_pub_key"`
 	DeviceKeyType string `json:"device_key_type"`
+	// EncPubKey is the machine's X25519 key; other machines wrap content keys to it.
+	EncPubKey string `json:"enc_pub_key,omitempty"`
+	// EncPubKeySig signs EncPubKey with the device key. Machines that trust
+	// this one check it against their pinned copy of the device key.
+	EncPubKeySig string `json:"enc_pub_key_sig,omitempty"`
 }
 
 func registerMachine(ctx context.Context, accessToken string) error {
@@ -49,11 +54,23 @@ func registerMachine(ctx context.Context, accessToken string) error {
 		return err
 	}
 
+	encPub, err := auth.GetOrCreateDeviceEncryptionPublicKey()
+	if err != nil {
+		return err
+	}
+
+	encSig, err := auth.SignEncPubKey(cfg.MachineID, encPub)
+	if err != nil {
+		return err
+	}
+
 	payload := registerMachineRequest{
 		MachineID:     cfg.MachineID,
 		MachineName:   name,
 		DevicePubKey:  pub,
 		DeviceKeyType: "ed25519",
+		EncPubKey:     encPub,
+		EncPubKeySig:  encSig,
 	}
 	b, err := json.Marshal(payload)
 	if err != nil {
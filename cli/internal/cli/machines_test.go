@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+)
+
+func TestCheckMachineTrust(t *testing.T) {
+	newTestHome(t)
+	devicePub, err := auth.GetOrCreateDevicePublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encPub, err := auth.GetOrCreateDeviceEncryptionPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := auth.SignEncPubKey("m1", encPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genuine := remoteMachine{MachineID: "m1", MachineName: "laptop", DevicePubKey: devicePub, EncPubKey: encPub, EncPubKeySig: sig}
+
+	trusted, err := auth.LoadTrustedMachines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := checkMachineTrust(genuine, trusted); got != machineUntrusted {
+		t.Fatalf("before approval: %v, want untrusted", got)
+	}
+
+	if err := auth.TrustMachine("m1", "laptop", devicePub); err != nil {
+		t.Fatal(err)
+	}
+	if trusted, err = auth.LoadTrustedMachines(); err != nil {
+		t.Fatal(err)
+	}
+	if got := checkMachineTrust(genuine, trusted); got != machineTrusted {
+		t.Fatalf("approved: %v, want trusted", got)
+	}
+
+	// The server swaps in its own encryption key, keeping the old signature.
+	swapped := genuine
+	swapped.EncPubKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	if got := checkMachineTrust(swapped, trusted); got != machineKeysChanged {
+		t.Fatalf("swapped encryption key: %v, want keys changed", got)
+	}
+
+	// ... or a device key of its own that signed its encryption key.
+	forged := genuine
+	forged.DevicePubKey = "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	if got := checkMachineTrust(forged, trusted); got != machineKeysChanged {
+		t.Fatalf("swapped device key: %v, want keys changed", got)
+	}
+
+	unsigned := genuine
+	unsigned.EncPubKeySig = ""
+	if got := checkMachineTrust(unsigned, trusted); got != machineKeysChanged {
+		t.Fatalf("unsigned encryption key: %v, want keys changed", got)
+	}
+}
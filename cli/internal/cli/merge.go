@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mgeovany/sentra/cli/internal/dotenv"
+)
+
+var errSyncAborted = errors.New("sync aborted")
+
+// keyConflict is a key changed differently on both sides since the base.
+type keyConflict struct {
+	Key      string
+	Local    string
+	Remote   string
+	InLocal  bool
+	InRemote bool
+}
+
+const (
+	takeLocal  = 'l'
+	takeRemote = 'r'
+)
+
+// mergeEnv does a three-way, key-level merge of remote into local. Edits to
+// different keys are combined; a key changed on both sides to different
+// values is a conflict unless resolve says which side wins. The result keeps
+// local formatting and comments. A nil base means there is no common
+// ancestor, so only keys that agree (or exist on one side) merge cleanly.
+func mergeEnv(base, local, remote []byte, resolve map[string]byte) ([]byte, []keyConflict) {
+	baseFile, localFile, remoteFile := dotenv.Parse(base), dotenv.Parse(local), dotenv.Parse(remote)
+	b, l, r := baseFile.Map(), localFile.Map(), remoteFile.Map()
+
+	var keys []string
+	seen := map[string]struct{}{}
+	for _, f := range []*dotenv.File{localFile, remoteFile, baseFile} {
+		for _, k := range f.Keys() {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	var conflicts []keyConflict
+	for _, k := range keys {
+		bv, bok := b[k]
+		lv, lok := l[k]
+		rv, rok := r[k]
+
+		var take byte
+		switch {
+		case lok == rok && lv == rv:
+			continue
+		case lok == bok && lv == bv:
+			take = takeRemote
+		case rok == bok && rv == bv:
+			continue
+		default:
+			take = resolve[k]
+		}
+
+		switch take {
+		case takeRemote:
+			if rok {
+				localFile.Set(k, rv)
+			} else {
+				localFile.Delete(k)
+			}
+		case takeLocal:
+		default:
+			conflicts = append(conflicts, keyConflict{Key: k, Local: lv, Remote: rv, InLocal: lok, InRemote: rok})
+		}
+	}
+	return localFile.Bytes(), conflicts
+}
+
+// printConflicts reports conflicting keys without revealing values.
+func printConflicts(path string, conflicts []keyConflict) {
+	fmt.Println(c(ansiRed, "✖ ") + c(ansiBold, path) + c(ansiRed, fmt.Sprintf(": %d conflicting key(s)", len(conflicts))))
+	for _, cf := range conflicts {
+		fmt.Println("  " + c(ansiYellow, "! "+cf.Key) + " " + c(ansiDim, conflictSummary(cf)))
+	}
+}
+
+func conflictSummary(cf keyConflict) string {
+	switch {
+	case !cf.InLocal:
+		return "(deleted locally, changed remotely)"
+	case !cf.InRemote:
+		return "(changed locally, deleted remotely)"
+	default:
+		return "(changed locally and remotely)"
+	}
+}
+
+// resolveConflictsInteractive asks which side wins for every conflicting key.
+func resolveConflictsInteractive(path string, conflicts []keyConflict) (map[string]byte, error) {
+	r := bufio.NewReader(os.Stdin)
+	out := make(map[string]byte, len(conflicts))
+	fmt.Println(c(ansiYellow, "⚠ ") + c(ansiBold, path) + c(ansiYellow, fmt.Sprintf(" has %d conflicting key(s)", len(conflicts))))
+	for _, cf := range conflicts {
+		reveal := false
+		for {
+			fmt.Println()
+			fmt.Println(c(ansiBold, cf.Key) + " " + c(ansiDim, conflictSummary(cf)))
+			fmt.Println("  local:  " + conflictValue(cf.Local, cf.InLocal, reveal))
+			fmt.Println("  remote: " + conflictValue(cf.Remote, cf.InRemote, reveal))
+
+			options := []string{"Keep local", "Take remote", "Show values", "Abort sync"}
+			if reveal {
+				options[2] = "Hide values"
+			}
+			n, err := promptSelect(r, options)
+			if err != nil {
+				return nil, err
+			}
+			switch n {
+			case 1:
+				out[cf.Key] = takeLocal
+			case 2:
+				out[cf.Key] = takeRemote
+			case 3:
+				reveal = !reveal
+				continue
+			default:
+				return nil, errSyncAborted
+			}
+			break
+		}
+	}
+	fmt.Println()
+	return out, nil
+}
+
+func conflictValue(v string, present bool, reveal bool) string {
+	if !present {
+		return c(ansiDim, "(deleted)")
+	}
+	if !reveal {
+		return maskedValue
+	}
+	return v
+}
@@ -35,7 +35,7 @@ func runOverview(args []string) error {
 	}
 
 	sp := startSpinner("Building project overview...")
-	projects, err := scanner.Scan(scanRoot)
+	projects, err := scanProjects(scanRoot)
 	if err != nil {
 		sp.StopInfo("")
 		return err
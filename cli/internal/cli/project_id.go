@@ -0,0 +1,497 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+)
+
+// Locally a project is a repository the scanner found under the scan root,
+// known by its path from there ("api", "acme/api"). A worktree folded into its
+// main checkout (worktrees: main) counts as that checkout. Remotely a project
+// is a stable ID, so renaming the folder, or cloning it elsewhere on another
+// machine, still reaches the same remote project. The ID is, in order:
+//
+//   - the one pinned on this machine (sentra projects migrate|link),
+//   - the contents of a committed .sentra/project-id file,
+//   - the origin remote, normalized (git@github.com:Org/api.git → github.com/org/api),
+//     unless the project was pushed under its folder name before IDs and not
+//     under this ID since: it stays on the folder name until migrated,
+//   - the folder's path, which is what projects were keyed by before IDs.
+//
+// Remote file paths are the ID followed by the path inside the project.
+const projectIDFile = ".sentra/project-id"
+
+var (
+	projectIDPattern   = regexp.MustCompile(`^[A-Za-z0-9._-]+(?:/[A-Za-z0-9._-]+)*$`)
+	projectIDUnsafeRun = regexp.MustCompile(`[^a-z0-9._-]+`)
+)
+
+// localProject is a repository the scanner found.
+type localProject struct {
+	// dir is its path from the scan root, "." for the scan root itself.
+	// Scanned files are relative to it.
+	dir string
+	// root is the project it counts as: dir, or the main checkout a worktree
+	// is folded into. Commit parents and push requests are keyed by it.
+	root string
+}
+
+// projectIDs maps the projects under a scan root to their IDs and back, and
+// paths under the scan root to their project.
+type projectIDs struct {
+	scanRoot string
+	// projects is ordered deepest first, so a nested repository is matched
+	// before the one around it.
+	projects []localProject
+	byRoot   map[string]string
+	byID     map[string]string
+}
+
+var (
+	loadedProjectIDs = map[string]*projectIDs{}
+	// scannedProjects is the last scan of each scan root in this process.
+	scannedProjects = map[string][]scanner.Project{}
+)
+
+// loadProjectIDs reads the ID of every project the scanner finds under
+// scanRoot, reusing this process's last scan of it. The result is kept for
+// the rest of the process.
+func loadProjectIDs(scanRoot string) (*projectIDs, error) {
+	scanRoot = filepath.Clean(strings.TrimSpace(scanRoot))
+	if ids, ok := loadedProjectIDs[scanRoot]; ok {
+		return ids, nil
+	}
+	ids := &projectIDs{scanRoot: scanRoot, byRoot: map[string]string{}, byID: map[string]string{}}
+
+	scanned, ok := scannedProjects[scanRoot]
+	if !ok && isDir(scanRoot) {
+		var err error
+		if scanned, err = scanProjects(scanRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	// Main checkouts claim an ID before worktrees and other clones of the
+	// same repository.
+	type candidate struct {
+		root string
+		main bool
+	}
+	var candidates []candidate
+	for _, p := range scanned {
+		dir, ok := scanRel(scanRoot, p.RootPath)
+		if !ok {
+			continue
+		}
+		lp := localProject{dir: dir, root: dir}
+		if p.Folded {
+			if main, ok := scanRel(scanRoot, p.MainRoot); ok {
+				lp.root = main
+			}
+		}
+		ids.projects = append(ids.projects, lp)
+		if lp.root == dir {
+			candidates = append(candidates, candidate{root: dir, main: p.MainRoot == ""})
+		}
+	}
+	depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return strings.Count(dir, "/") + 1
+	}
+	sort.SliceStable(ids.projects, func(i, j int) bool { return depth(ids.projects[i].dir) > depth(ids.projects[j].dir) })
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].main && !candidates[j].main })
+
+	pins, err := loadProjectIDPins()
+	if err != nil {
+		return nil, err
+	}
+	var legacy *legacyProjectKeys
+	for _, cand := range candidates {
+		dir := filepath.Join(scanRoot, filepath.FromSlash(cand.root))
+		id := pins.Projects[dir]
+		if id == "" {
+			if id, err = readCommittedProjectID(dir); err != nil {
+				return nil, err
+			}
+		}
+		if id == "" {
+			id = originProjectID(dir)
+			if id != "" && !strings.Contains(cand.root, "/") {
+				if legacy == nil {
+					if legacy, err = loadLegacyProjectKeys(); err != nil {
+						return nil, err
+					}
+				}
+				if legacy.keeps(cand.root, id) {
+					verbosef("%s stays on its folder name remotely; to move it to %s, run: sentra projects migrate %s", cand.root, id, cand.root)
+					id = cand.root
+				}
+			}
+		}
+		if id == "" {
+			id = cand.root
+		}
+		ids.byRoot[cand.root] = id
+		if other, ok := ids.byID[id]; ok {
+			verbosef("%s and %s are both project %s; sync writes to %s", other, cand.root, id, other)
+			continue
+		}
+		ids.byID[id] = cand.root
+	}
+	loadedProjectIDs[scanRoot] = ids
+	return ids, nil
+}
+
+// scanRel returns p relative to scanRoot, slash-separated, if p is inside it.
+func scanRel(scanRoot, p string) (string, bool) {
+	if strings.TrimSpace(p) == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(scanRoot, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// currentProjectIDs is loadProjectIDs for the scan root in the index,
+// without prompting for one.
+func currentProjectIDs() (*projectIDs, error) {
+	scanRoot, err := resolveScanRootFromIndex()
+	if err != nil {
+		return nil, err
+	}
+	return loadProjectIDs(scanRoot)
+}
+
+// readProjectID returns the ID committed in dir or derived from its origin,
+// or "" if it has neither.
+func readProjectID(dir string) (string, error) {
+	id, err := readCommittedProjectID(dir)
+	if err != nil || id != "" {
+		return id, err
+	}
+	return originProjectID(dir), nil
+}
+
+// readCommittedProjectID returns the ID in dir's .sentra/project-id, or "".
+func readCommittedProjectID(dir string) (string, error) {
+	p := filepath.Join(dir, filepath.FromSlash(projectIDFile))
+	b, err := os.ReadFile(p)
+	switch {
+	case err == nil:
+		id := strings.TrimSpace(string(b))
+		if !validProjectID(id) {
+			return "", fmt.Errorf("invalid project id in %s: %q (use letters, digits, '.', '_', '-' and '/' between parts)", p, id)
+		}
+		return id, nil
+	case os.IsNotExist(err):
+		return "", nil
+	default:
+		return "", err
+	}
+}
+
+// originProjectID returns the ID derived from dir's origin remote, or "".
+func originProjectID(dir string) string {
+	origin := scanner.OriginURL(dir)
+	if origin == "" {
+		// No origin: fall back to the folder name.
+		return ""
+	}
+	return normalizeRemoteURL(origin)
+}
+
+// projectIDPins are the IDs set on this machine with sentra projects migrate
+// or link, by absolute project directory. They win over everything else.
+type projectIDPins struct {
+	V        int               `json:"v"`
+	Projects map[string]string `json:"projects"`
+}
+
+func projectIDPinsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sentra", "project-ids.json"), nil
+}
+
+func loadProjectIDPins() (projectIDPins, error) {
+	p, err := projectIDPinsPath()
+	if err != nil {
+		return projectIDPins{}, err
+	}
+	pins := projectIDPins{V: 1, Projects: map[string]string{}}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pins, nil
+		}
+		return projectIDPins{}, err
+	}
+	if err := json.Unmarshal(b, &pins); err != nil {
+		return projectIDPins{}, fmt.Errorf("invalid project ids file %s", p)
+	}
+	if pins.Projects == nil {
+		pins.Projects = map[string]string{}
+	}
+	return pins, nil
+}
+
+// pinProjectID makes id the ID of the project at dir on this machine.
+func pinProjectID(dir, id string) error {
+	pins, err := loadProjectIDPins()
+	if err != nil {
+		return err
+	}
+	pins.V = 1
+	pins.Projects[filepath.Clean(dir)] = id
+
+	p, err := projectIDPinsPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmpPath := p + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	// Later lookups in this process see the new ID.
+	loadedProjectIDs = map[string]*projectIDs{}
+	return nil
+}
+
+// legacyProjectKeys is what this machine knows of projects pushed under their
+// folder name, which is how projects were keyed before IDs.
+type legacyProjectKeys struct {
+	heads commit.Heads
+	// pushed holds the folders with files in a pushed commit.
+	pushed map[string]bool
+}
+
+func loadLegacyProjectKeys() (*legacyProjectKeys, error) {
+	heads, err := commit.LoadHeads()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := commit.List()
+	if err != nil {
+		return nil, err
+	}
+	l := &legacyProjectKeys{heads: heads, pushed: map[string]bool{}}
+	for _, c := range commits {
+		if strings.TrimSpace(c.PushedAt) == "" {
+			continue
+		}
+		for _, p := range c.Paths() {
+			l.pushed[projectRootFromPath(p)] = true
+		}
+	}
+	return l, nil
+}
+
+// keeps reports whether folder should stay on its folder name rather than
+// move to id: it has remote history under the name, and none under id yet.
+// Moving it is an explicit step (sentra projects migrate), as its files then
+// start a new history.
+func (l *legacyProjectKeys) keeps(folder, id string) bool {
+	if _, ok := l.heads.Projects[id]; ok {
+		return false
+	}
+	_, hasHead := l.heads.Projects[folder]
+	return hasHead || l.pushed[folder]
+}
+
+// normalizeRemoteURL turns the spellings of a remote into one ID: scheme,
+// user, port and a trailing .git are dropped and the rest is lowercased, so
+// git@github.com:Org/api.git and https://github.com/org/api give
+// github.com/org/api. Local paths have no stable ID and return "".
+func normalizeRemoteURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	var host, p string
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "file" {
+			return ""
+		}
+		host, p = u.Hostname(), u.Path
+	} else {
+		// scp-like syntax: [user@]host:path. A colon after a slash, or after a
+		// drive letter, is a local path.
+		before, after, ok := strings.Cut(raw, ":")
+		if !ok || strings.Contains(before, "/") || len(before) == 1 {
+			return ""
+		}
+		if _, h, ok := strings.Cut(before, "@"); ok {
+			before = h
+		}
+		host, p = before, after
+	}
+	if host == "" {
+		return ""
+	}
+
+	parts := []string{strings.ToLower(host)}
+	for _, seg := range strings.Split(strings.TrimSuffix(strings.Trim(p, "/"), ".git"), "/") {
+		seg = strings.Trim(projectIDUnsafeRun.ReplaceAllString(strings.ToLower(seg), "-"), "-")
+		if seg == "" || seg == "." || seg == ".." {
+			continue
+		}
+		parts = append(parts, seg)
+	}
+	if len(parts) < 2 {
+		return ""
+	}
+	id := strings.Join(parts, "/")
+	if !validProjectID(id) {
+		return ""
+	}
+	return id
+}
+
+func validProjectID(id string) bool {
+	if len(id) > 200 || !projectIDPattern.MatchString(id) {
+		return false
+	}
+	for _, seg := range strings.Split(id, "/") {
+		if seg == "." || seg == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// remoteID returns the ID of the local project root.
+func (ids *projectIDs) remoteID(root string) string {
+	if id, ok := ids.byRoot[root]; ok {
+		return id
+	}
+	return root
+}
+
+// localRoot returns the folder holding project id on this machine.
+func (ids *projectIDs) localRoot(id string) (string, bool) {
+	if root, ok := ids.byID[id]; ok {
+		return root, true
+	}
+	// A folder of that name is the project only if it has no other ID.
+	if _, claimed := ids.byRoot[id]; !claimed && !strings.Contains(id, "/") && isDir(filepath.Join(ids.scanRoot, id)) {
+		return id, true
+	}
+	return "", false
+}
+
+// project returns the scanned project holding p (a path from the scan root),
+// or the project p names.
+func (ids *projectIDs) project(p string) (localProject, bool) {
+	p = normalizeRelPath(p)
+	for _, lp := range ids.projects {
+		if lp.dir == "." || p == lp.dir || strings.HasPrefix(p, lp.dir+"/") {
+			return lp, true
+		}
+	}
+	return localProject{}, false
+}
+
+// rootOf returns the root of the project holding p. Paths outside every
+// scanned project, such as those of a project since removed, fall back to
+// their first segment.
+func (ids *projectIDs) rootOf(p string) string {
+	if lp, ok := ids.project(p); ok {
+		return lp.root
+	}
+	return projectRootFromPath(p)
+}
+
+// dirOf returns the directory of the checkout holding p, which for a
+// worktree folded into its main checkout is the worktree, not its root.
+func (ids *projectIDs) dirOf(p string) string {
+	if lp, ok := ids.project(p); ok {
+		return lp.dir
+	}
+	return projectRootFromPath(p)
+}
+
+// relInProject returns p relative to the repository holding it: the path
+// the scanner hashes it under (see scanner.HashEnv) and, with the project's
+// ID in front, its remote path.
+func (ids *projectIDs) relInProject(p string) string {
+	p = normalizeRelPath(p)
+	if lp, ok := ids.project(p); ok {
+		if lp.dir == "." {
+			return p
+		}
+		return strings.TrimPrefix(p, lp.dir+"/")
+	}
+	return strings.TrimPrefix(p, projectRootFromPath(p)+"/")
+}
+
+// remotePath turns a local path ("api/.env") into the remote one
+// ("github.com/org/api/.env").
+func (ids *projectIDs) remotePath(p string) string {
+	return ids.remoteID(ids.rootOf(p)) + "/" + ids.relInProject(p)
+}
+
+// localPath turns a remote path of project id into the path under the local
+// root, or returns false if it is not inside the project.
+func localPath(id, root, remotePath string) (string, bool) {
+	rel, ok := strings.CutPrefix(strings.TrimSpace(remotePath), id+"/")
+	if !ok {
+		return "", false
+	}
+	if root == "." {
+		return rel, true
+	}
+	return root + "/" + rel, true
+}
+
+// resolveProject reads a project argument: a local folder (or a path inside
+// it) or a remote ID. It returns the ID and, if the project is checked out
+// here, its local root.
+func (ids *projectIDs) resolveProject(arg string) (id string, root string) {
+	arg = strings.Trim(strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(arg)), "./"), "/")
+	if r, ok := ids.byID[arg]; ok {
+		return arg, r
+	}
+	if lp, ok := ids.project(arg); ok && arg != "" {
+		return ids.remoteID(lp.root), lp.root
+	}
+	first := projectRootFromPath(arg)
+	if r, ok := ids.localRoot(first); ok {
+		return first, r
+	}
+	return arg, ""
+}
+
+// remoteProjectID is resolveProject against the scan root in the index, for
+// commands that only talk to the remote.
+func remoteProjectID(arg string) (string, error) {
+	ids, err := currentProjectIDs()
+	if err != nil {
+		return "", err
+	}
+	id, _ := ids.resolveProject(arg)
+	return id, nil
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/index"
+	"github.com/mgeovany/sentra/cli/internal/storage"
+)
+
+func TestProjectLayout(t *testing.T) {
+	scanRoot := newTestLayout(t)
+
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path, root, dir, rel string
+	}{
+		{"acme/api/.env", "acme/api", "acme/api", ".env"},
+		{"acme/api-feature/.env.local", "acme/api", "acme/api-feature", ".env.local"},
+		{"app/.env", "app", "app", ".env"},
+		{"app/libs/auth/.env", "app/libs/auth", "app/libs/auth", ".env"},
+		{"gone/.env", "gone", "gone", ".env"},
+	}
+	for _, tt := range tests {
+		if got := ids.rootOf(tt.path); got != tt.root {
+			t.Errorf("rootOf(%s) = %s, want %s", tt.path, got, tt.root)
+		}
+		if got := ids.dirOf(tt.path); got != tt.dir {
+			t.Errorf("dirOf(%s) = %s, want %s", tt.path, got, tt.dir)
+		}
+		if got := ids.relInProject(tt.path); got != tt.rel {
+			t.Errorf("relInProject(%s) = %s, want %s", tt.path, got, tt.rel)
+		}
+	}
+	if id, root := ids.resolveProject("acme/api-feature"); id != "acme/api" || root != "acme/api" {
+		t.Fatalf("resolveProject(acme/api-feature) = %s, %s", id, root)
+	}
+	if id, root := ids.resolveProject("app/libs/auth"); id != "app/libs/auth" || root != "app/libs/auth" {
+		t.Fatalf("resolveProject(app/libs/auth) = %s, %s", id, root)
+	}
+}
+
+func TestPushSyncLayout(t *testing.T) {
+	scanRoot := newTestLayout(t)
+	recipients := selfRecipients(t)
+
+	c := layoutCommit(t, scanRoot, "acme/api/.env", "acme/api-feature/.env.local", "app/.env", "app/libs/auth/.env")
+	reqs, err := buildPushRequestV1(context.Background(), scanRoot, "machine-a", "a", c, recipients, storage.S3Config{}, nil, false, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, req := range reqs {
+		for _, f := range req.Files {
+			got[req.Project.Root] = append(got[req.Project.Root], f.Path)
+		}
+	}
+	want := map[string][]string{
+		"acme/api":      {"acme/api/.env", "acme/api/.env.local"},
+		"app":           {"app/.env"},
+		"app/libs/auth": {"app/libs/auth/.env"},
+	}
+	for _, paths := range got {
+		sort.Strings(paths)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pushed %v, want %v", got, want)
+	}
+
+	// Sync writes each project's files back to its own checkout.
+	for _, req := range reqs {
+		id := req.Project.Root
+		remote := exportAfterPush(map[string]remoteExportFile{}, []pushRequestV1{req})
+		for _, f := range req.Files {
+			rel, _ := localPath(id, id, f.Path)
+			if err := os.Remove(filepath.Join(scanRoot, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+		}
+		actions, err := planProjectSync(scanRoot, id, id, remote, commit.Heads{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rels []string
+		for _, a := range actions {
+			if a.Kind != syncCreate {
+				t.Fatalf("%s: %v, want create", a.Rel, a.Kind)
+			}
+			rels = append(rels, a.Rel)
+		}
+		sort.Strings(rels)
+		if !reflect.DeepEqual(rels, want[id]) {
+			t.Fatalf("%s: synced %v, want %v", id, rels, want[id])
+		}
+	}
+}
+
+func TestPushRefusesFoldedDuplicate(t *testing.T) {
+	scanRoot := newTestLayout(t)
+	writeTestFile(t, filepath.Join(scanRoot, "acme", "api-feature", ".env"), "A=feature\n")
+
+	c := layoutCommit(t, scanRoot, "acme/api/.env", "acme/api-feature/.env")
+	_, err := buildPushRequestV1(context.Background(), scanRoot, "machine-a", "a", c, nil, storage.S3Config{}, nil, false, "user-1")
+	if err == nil || !strings.Contains(err.Error(), "are both acme/api/.env") {
+		t.Fatalf("err = %v, want the duplicate refused", err)
+	}
+}
+
+// Sync, restore and revert record what they write under the hash the
+// scanner gives the file, so status sees it in sync.
+// gitClone makes dir a repository whose origin is url.
+func gitClone(t *testing.T, dir, url string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, args := range [][]string{{"init", "-q", dir}, {"-C", dir, "remote", "add", "origin", url}} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestNestedProjectIDs(t *testing.T) {
+	scanRoot := newTestLayout(t)
+	gitClone(t, filepath.Join(scanRoot, "acme", "api"), "git@github.com:Acme/api.git")
+	writeTestFile(t, filepath.Join(scanRoot, "app", "libs", "auth", ".sentra", "project-id"), "acme/auth\n")
+
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for root, want := range map[string]string{
+		"acme/api":      "github.com/acme/api",
+		"app":           "app",
+		"app/libs/auth": "acme/auth",
+	} {
+		if got := ids.remoteID(root); got != want {
+			t.Errorf("remoteID(%s) = %s, want %s", root, got, want)
+		}
+		if got, ok := ids.localRoot(want); !ok || got != root {
+			t.Errorf("localRoot(%s) = %s, %v, want %s", want, got, ok, root)
+		}
+	}
+	if got := ids.remotePath("acme/api-feature/.env.local"); got != "github.com/acme/api/.env.local" {
+		t.Errorf("remotePath of the folded worktree's file = %s", got)
+	}
+}
+
+func TestLegacyProjectKey(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	api := filepath.Join(scanRoot, "api")
+	gitClone(t, api, "https://github.com/acme/api")
+	writeTestFile(t, filepath.Join(api, ".env"), "A=1\n")
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Save(indexPath, index.Index{ScanRoot: scanRoot}); err != nil {
+		t.Fatal(err)
+	}
+	remoteID := func() string {
+		t.Helper()
+		loadedProjectIDs = map[string]*projectIDs{}
+		ids, err := loadProjectIDs(scanRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids.remoteID("api")
+	}
+
+	if got := remoteID(); got != "github.com/acme/api" {
+		t.Fatalf("never pushed: %s", got)
+	}
+
+	// Pushed under its folder name before IDs: it stays there.
+	pushed := layoutCommit(t, scanRoot, "api/.env")
+	pushed.PushedAt = "2026-01-02T03:04:05Z"
+	if _, err := commit.Save(pushed); err != nil {
+		t.Fatal(err)
+	}
+	if got := remoteID(); got != "api" {
+		t.Fatalf("pushed under the folder name: %s, want api", got)
+	}
+
+	// Until it is migrated.
+	if err := runProjectsMigrate("api"); err != nil {
+		t.Fatal(err)
+	}
+	if got := remoteID(); got != "github.com/acme/api" {
+		t.Fatalf("migrated: %s", got)
+	}
+
+	// Or linked to an ID of the user's choosing.
+	if err := runProjectsLink("api", "acme/legacy-api"); err != nil {
+		t.Fatal(err)
+	}
+	if got := remoteID(); got != "acme/legacy-api" {
+		t.Fatalf("linked: %s", got)
+	}
+	if err := runProjectsLink("api", "../x"); err == nil {
+		t.Fatal("linked an invalid id")
+	}
+}
+
+func TestLegacyProjectKeyAfterPushUnderID(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	api := filepath.Join(scanRoot, "api")
+	gitClone(t, api, "https://github.com/acme/api")
+	writeTestFile(t, filepath.Join(api, ".env"), "A=1\n")
+	pushed := layoutCommit(t, scanRoot, "api/.env")
+	pushed.PushedAt = "2026-01-02T03:04:05Z"
+	if _, err := commit.Save(pushed); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the ID has a remote head here, the folder name no longer holds it.
+	if err := commit.SetHead("github.com/acme/api", pushed.ID); err != nil {
+		t.Fatal(err)
+	}
+	loadedProjectIDs = map[string]*projectIDs{}
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids.remoteID("api"); got != "github.com/acme/api" {
+		t.Fatalf("remoteID = %s", got)
+	}
+}
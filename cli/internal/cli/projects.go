@@ -3,9 +3,11 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -15,9 +17,98 @@ type remoteProject struct {
 	LastCommitID      string `json:"last_commit_id"`
 	LastCommitMessage string `json:"last_commit_message"`
 	FileCount         int    `json:"file_count"`
+	// LastClientID is the client-generated id of the head commit; local
+	// commits use it as their parent after a sync.
+	LastClientID string `json:"last_client_id,omitempty"`
 }
 
-func runProjects() error {
+const projectsUsage = "usage: sentra projects [migrate <folder> | link <folder> <id>]"
+
+func runProjects(args []string) error {
+	switch {
+	case len(args) == 0:
+		return listRemoteProjects()
+	case args[0] == "migrate" && len(args) == 2:
+		return runProjectsMigrate(args[1])
+	case args[0] == "link" && len(args) == 3:
+		return runProjectsLink(args[1], args[2])
+	default:
+		return errors.New(projectsUsage)
+	}
+}
+
+// sentra projects migrate <folder>
+//
+// Moves a project still pushed under its folder name to the ID from its
+// .sentra/project-id or origin. Its history under the folder name stays on
+// the remote; the next push starts the new one.
+func runProjectsMigrate(folder string) error {
+	ids, root, dir, err := checkedOutProject(folder)
+	if err != nil {
+		return err
+	}
+	id, err := readProjectID(dir)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("%s has no origin remote or %s to migrate to; pick an ID with: sentra projects link %s <id>", root, projectIDFile, root)
+	}
+	old := ids.remoteID(root)
+	if old == id {
+		successf("✔ %s is already project %s", root, id)
+		return nil
+	}
+	if err := pinProjectID(dir, id); err != nil {
+		return err
+	}
+	successf("✔ %s is now project %s", root, id)
+	infof("Its history stays under %s. Push its files under the new ID with: sentra add %s && sentra commit -m \"Move to %s\" && sentra push", old, root, id)
+	return nil
+}
+
+// sentra projects link <folder> <id>
+//
+// Sets the ID of a project on this machine, e.g. to keep pushing to the
+// project another machine pushed it under.
+func runProjectsLink(folder, id string) error {
+	id = strings.Trim(strings.TrimSpace(id), "/")
+	if !validProjectID(id) {
+		return fmt.Errorf("invalid project id: %q (use letters, digits, '.', '_', '-' and '/' between parts)", id)
+	}
+	ids, root, dir, err := checkedOutProject(folder)
+	if err != nil {
+		return err
+	}
+	if other, ok := ids.byID[id]; ok && other != root {
+		return fmt.Errorf("%s is already project %s", other, id)
+	}
+	if err := pinProjectID(dir, id); err != nil {
+		return err
+	}
+	successf("✔ %s is now project %s", root, id)
+	return nil
+}
+
+// checkedOutProject finds the scanned project folder names under the scan
+// root and returns its root and directory.
+func checkedOutProject(folder string) (ids *projectIDs, root string, dir string, err error) {
+	scanRoot, err := resolveScanRoot()
+	if err != nil {
+		return nil, "", "", err
+	}
+	if ids, err = loadProjectIDs(scanRoot); err != nil {
+		return nil, "", "", err
+	}
+	folder = normalizeRelPath(folder)
+	lp, ok := ids.project(folder)
+	if !ok {
+		return nil, "", "", fmt.Errorf("no project %s under %s", folder, scanRoot)
+	}
+	return ids, lp.root, filepath.Join(scanRoot, filepath.FromSlash(lp.root)), nil
+}
+
+func listRemoteProjects() error {
 	verbosef("Fetching projects from remote...")
 	sess, err := ensureRemoteSession()
 	if err != nil {
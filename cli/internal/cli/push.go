@@ -8,7 +8,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -85,7 +84,8 @@ func runPush() error {
 	}
 
 	verbosef("Found %d pending commit(s) to push", len(pending))
-	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+	// Oldest first so each commit's parent reaches the server before it does.
+	commit.Sort(pending)
 
 	cfg, err := auth.EnsureConfig()
 	if err != nil {
@@ -116,6 +116,18 @@ func runPush() error {
 	if err != nil {
 		return err
 	}
+	warnLegacyCiphersFile()
+
+	// Every registered machine gets a wrapped copy of each file's content key.
+	recipients, err := func() ([]auth.Recipient, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		return fetchEncryptionRecipients(ctx, serverURL, sess.AccessToken, machineID)
+	}()
+	if err != nil {
+		return err
+	}
+	verbosef("Encrypting to %d machine(s)", len(recipients))
 
 	client := &http.Client{Timeout: 20 * time.Second}
 
@@ -166,7 +178,7 @@ func runPush() error {
 		}
 
 		verbosef("Building push request for commit %s...", c.ID)
-		reqs, err := buildPushRequestV1(context.Background(), scanRoot, machineID, name, c, s3cfg, s3c, byos, userID)
+		reqs, err := buildPushRequestV1(context.Background(), scanRoot, machineID, name, c, recipients, s3cfg, s3c, byos, userID)
 		if err != nil {
 			sp.StopInfo("")
 			return err
@@ -237,6 +249,21 @@ func runPush() error {
 					continue
 				}
 
+				if resp.StatusCode == http.StatusConflict {
+					var conflict pushConflictV1
+					if json.Unmarshal(respBody, &conflict) == nil && conflict.Error == pushErrParentMismatch {
+						sp.StopInfo("")
+						root := strings.TrimSpace(reqBody.Project.Root)
+						head := strings.TrimSpace(conflict.HeadClientID)
+						verbosef("Push rejected: parent=%s, remote head=%s", reqBody.Commit.ParentClientID, head)
+						// Remember the head so the next commit for this project chains onto it.
+						if head != "" {
+							_ = commit.SetHead(root, head)
+						}
+						return fmt.Errorf("push rejected: %s has newer commits on the remote (commit %s is not based on the remote head). Fix: run sentra sync, drop this commit (sentra log rm %s), then add and commit again", root, shortID, shortID)
+					}
+				}
+
 				if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 					msg := oneLine(string(respBody))
 					if msg == "" {
@@ -252,6 +279,9 @@ func runPush() error {
 				}
 
 				verbosef("Successfully pushed project %s", reqBody.Project.Root)
+				if err := commit.SetHead(reqBody.Project.Root, reqBody.Commit.ClientID); err != nil {
+					verbosef("Failed to record remote head for %s: %v", reqBody.Project.Root, err)
+				}
 				break
 			}
 		}
@@ -261,6 +291,9 @@ func runPush() error {
 			sp.StopInfo("")
 			return err
 		}
+		if err := updateBaseline(scanRoot, c.Files, c.Deleted, true); err != nil {
+			verbosef("Failed to update status baseline: %v", err)
+		}
 		sp.StopSuccess(fmt.Sprintf("✔ pushed commit %s", shortID))
 		verbosef("Commit %s marked as pushed at %s", c.ID, now)
 	}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,19 +15,40 @@ import (
 	"github.com/google/uuid"
 	"github.com/mgeovany/sentra/cli/internal/auth"
 	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/objects"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
 	"github.com/mgeovany/sentra/cli/internal/storage"
 	"github.com/minio/minio-go/v7"
 )
 
-func buildPushRequestV1(ctx context.Context, scanRoot, machineID, machineName string, c commit.Commit, s3cfg storage.S3Config, s3 *minio.Client, byos bool, userID string) ([]pushRequestV1, error) {
+func buildPushRequestV1(ctx context.Context, scanRoot, machineID, machineName string, c commit.Commit, recipients []auth.Recipient, s3cfg storage.S3Config, s3 *minio.Client, byos bool, userID string) ([]pushRequestV1, error) {
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		return nil, err
+	}
+
 	pathsByRoot := map[string][]string{}
+	deletedByRoot := map[string][]string{}
 	for p := range c.Files {
-		root := projectRootFromPath(p)
+		root := ids.rootOf(p)
 		if root == "" {
 			continue
 		}
 		pathsByRoot[root] = append(pathsByRoot[root], p)
 	}
+	for _, p := range c.Deleted {
+		root := ids.rootOf(p)
+		if root == "" {
+			continue
+		}
+		if _, ok := c.Files[p]; ok {
+			continue
+		}
+		deletedByRoot[root] = append(deletedByRoot[root], p)
+		if _, ok := pathsByRoot[root]; !ok {
+			pathsByRoot[root] = nil
+		}
+	}
 	if len(pathsByRoot) == 0 {
 		return nil, fmt.Errorf("cannot determine project root")
 	}
@@ -48,20 +70,32 @@ func buildPushRequestV1(ctx context.Context, scanRoot, machineID, machineName st
 	for _, root := range roots {
 		paths := pathsByRoot[root]
 		sort.Strings(paths)
+		if err := checkRemotePaths(ids, root, append(append([]string(nil), paths...), deletedByRoot[root]...)); err != nil {
+			return nil, err
+		}
+		projectCipherName, err := projectCipher(scanRoot, filepath.Join(scanRoot, filepath.FromSlash(root)))
+		if err != nil {
+			return nil, err
+		}
+		// The remote knows the project by its ID, and its files by paths under it.
+		id := ids.remoteID(root)
 
 		files := make([]pushFileV1, 0, len(paths))
 		for _, p := range paths {
-			abs := filepath.Join(scanRoot, filepath.FromSlash(p))
-			plain, err := os.ReadFile(abs)
+			plain, err := commitFileContents(scanRoot, c, p)
 			if err != nil {
-				if os.IsNotExist(err) {
-					return nil, fmt.Errorf("cannot read %s: file not found at %s (commit=%s). Fix: restore the file, or run: sentra log prune %s (or sentra log rm %s)", p, abs, strings.TrimSpace(c.ID), strings.TrimSpace(c.ID), strings.TrimSpace(c.ID))
-				}
-				return nil, fmt.Errorf("cannot read %s: %w", p, err)
+				return nil, err
 			}
 
+			// What is pushed must be what was committed: a corrupted snapshot,
+			// or a legacy commit's file edited since, is not.
+			if h := c.Files[p]; scanner.HashEnv(ids.relInProject(p), plain) != h {
+				commitID := strings.TrimSpace(c.ID)
+				return nil, fmt.Errorf("cannot push %s: contents do not match commit %s. Fix: run: sentra log rm %s and commit it again", p, commitID, commitID)
+			}
 			shaPlain := auth.SHA256Hex(plain)
-			cipherName, blobB64, size, err := auth.EncryptEnvBlob(plain)
+			remotePath := ids.remotePath(p)
+			cipherName, blobB64, size, err := auth.EncryptEnvBlob(projectCipherName, plain, recipients, auth.BlobBinding{Root: id, Path: remotePath})
 			if err != nil {
 				return nil, err
 			}
@@ -76,7 +110,7 @@ func buildPushRequestV1(ctx context.Context, scanRoot, machineID, machineName st
 				if err != nil {
 					return nil, err
 				}
-				key := s3ObjectKey(userID, root, p, shaPlain)
+				key := s3ObjectKey(userID, id, remotePath, shaPlain)
 				if err := storage.PutObject(ctx, s3, s3cfg, key, raw); err != nil {
 					return nil, fmt.Errorf("s3 upload failed (%s): %w", p, err)
 				}
@@ -92,7 +126,7 @@ func buildPushRequestV1(ctx context.Context, scanRoot, machineID, machineName st
 			}
 
 			files = append(files, pushFileV1{
-				Path:      p,
+				Path:      remotePath,
 				SHA256:    shaPlain,
 				Size:      size,
 				Encrypted: true,
@@ -102,21 +136,71 @@ func buildPushRequestV1(ctx context.Context, scanRoot, machineID, machineName st
 			})
 		}
 
+		var deleted []pushDeletedV1
+		sort.Strings(deletedByRoot[root])
+		for _, p := range deletedByRoot[root] {
+			deleted = append(deleted, pushDeletedV1{Path: ids.remotePath(p)})
+		}
+
 		out = append(out, pushRequestV1{
 			V:       1,
-			Project: pushProjectV1{Root: strings.TrimSpace(root)},
+			Project: pushProjectV1{Root: id},
 			Machine: pushMachineV1{ID: machineID, Name: machineName},
 			Commit: pushCommitV1{
-				ClientID: clientID,
-				Message:  strings.TrimSpace(c.Message),
+				ClientID:       clientID,
+				Message:        strings.TrimSpace(c.Message),
+				ParentClientID: c.ParentFor(root),
 			},
-			Files: files,
+			Files:   files,
+			Deleted: deleted,
 		})
 	}
 
 	return out, nil
 }
 
+// checkRemotePaths refuses a commit that has two local paths of a project
+// under the same remote path, as a worktree folded into its main checkout
+// (worktrees: main) can when both have the file.
+func checkRemotePaths(ids *projectIDs, root string, paths []string) error {
+	seen := map[string]string{}
+	for _, p := range paths {
+		remotePath := ids.remotePath(p)
+		if other, ok := seen[remotePath]; ok {
+			return fmt.Errorf("%s and %s are both %s in project %s; commit only one of them", other, p, remotePath, root)
+		}
+		seen[remotePath] = p
+	}
+	return nil
+}
+
+// commitFileContents returns the plaintext recorded for p in c. Snapshotted
+// commits read from the local object store; legacy commits (created before
+// the store existed) fall back to the working copy.
+func commitFileContents(scanRoot string, c commit.Commit, p string) ([]byte, error) {
+	commitID := strings.TrimSpace(c.ID)
+	if id, ok := c.ObjectID(p); ok {
+		plain, err := objects.Get(id)
+		if err != nil {
+			if errors.Is(err, objects.ErrNotFound) {
+				return nil, fmt.Errorf("cannot read %s: snapshot missing from local object store (commit=%s). Fix: run: sentra log rm %s", p, commitID, commitID)
+			}
+			return nil, fmt.Errorf("cannot read %s from local object store (commit=%s): %w", p, commitID, err)
+		}
+		return plain, nil
+	}
+
+	abs := filepath.Join(scanRoot, filepath.FromSlash(p))
+	plain, err := os.ReadFile(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot read %s: file not found at %s (commit=%s). Fix: restore the file, or run: sentra log prune %s (or sentra log rm %s)", p, abs, commitID, commitID, commitID)
+		}
+		return nil, fmt.Errorf("cannot read %s: %w", p, err)
+	}
+	return plain, nil
+}
+
 func s3ObjectKey(userID string, root string, path string, shaPlain string) string {
 	userID = strings.TrimSpace(userID)
 	root = strings.TrimSpace(root)
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mgeovany/sentra/cli/internal/objects"
+	"github.com/mgeovany/sentra/cli/internal/storage"
+)
+
+func TestPushRefusesMismatchedSnapshot(t *testing.T) {
+	scanRoot := newTestLayout(t)
+	recipients := selfRecipients(t)
+
+	c := layoutCommit(t, scanRoot, "app/libs/auth/.env")
+	if _, err := buildPushRequestV1(context.Background(), scanRoot, "machine-a", "a", c, recipients, storage.S3Config{}, nil, false, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The snapshot no longer holds what the commit recorded.
+	other, err := objects.Put([]byte("D=tampered\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Objects["app/libs/auth/.env"] = other
+	_, err = buildPushRequestV1(context.Background(), scanRoot, "machine-a", "a", c, recipients, storage.S3Config{}, nil, false, "user-1")
+	if err == nil || !strings.Contains(err.Error(), "contents do not match commit") {
+		t.Fatalf("err = %v, want the push aborted", err)
+	}
+}
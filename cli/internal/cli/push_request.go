@@ -6,6 +6,8 @@ type pushRequestV1 struct {
 	Machine pushMachineV1 `json:"machine"`
 	Commit  pushCommitV1  `json:"commit"`
 	Files   []pushFileV1  `json:"files"`
+	// Deleted are tombstones: paths this commit removes from the project.
+	Deleted []pushDeletedV1 `json:"deleted,omitempty"`
 }
 
 type pushProjectV1 struct {
@@ -34,6 +36,10 @@ type pushFileV1 struct {
 	Storage   *pushStorageV1 `json:"storage,omitempty"`
 }
 
+type pushDeletedV1 struct {
+	Path string `json:"path"`
+}
+
 type pushStorageV1 struct {
 	Provider string `json:"provider"`
 	Bucket   string `json:"bucket"`
@@ -41,3 +47,13 @@ type pushStorageV1 struct {
 	Endpoint string `json:"endpoint,omitempty"`
 	Region   string `json:"region,omitempty"`
 }
+
+// pushConflictV1 is the typed 409 body returned when commit.parent_client_id
+// is not the current remote head of the project.
+type pushConflictV1 struct {
+	Error        string `json:"error"`
+	Message      string `json:"message,omitempty"`
+	HeadClientID string `json:"head_client_id,omitempty"`
+}
+
+const pushErrParentMismatch = "parent_mismatch"
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/index"
+)
+
+// sentra reset [<path|project|glob>...]
+//
+// Unstages files (everything if no argument is given). The working copy is
+// not touched.
+func runReset(args []string) error {
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		return err
+	}
+	idx, _, err := index.Load(indexPath)
+	if err != nil {
+		return err
+	}
+
+	ids, err := currentProjectIDs()
+	if err != nil {
+		return err
+	}
+
+	staged := unionKeys(sortedKeys(idx.Staged), idx.Deleted)
+	var specs []string
+	for _, arg := range args {
+		specs = append(specs, strings.TrimPrefix(normalizeRelPath(arg), "./"))
+	}
+	if len(specs) == 0 {
+		specs = []string{"."}
+	}
+
+	var unstaged []string
+	for _, spec := range specs {
+		matched := false
+		for _, p := range staged {
+			if !matchPathspec(ids, spec, p) {
+				continue
+			}
+			matched = true
+			if idx.Unstage(p) {
+				unstaged = append(unstaged, p)
+				verbosef("  - %s", p)
+			}
+		}
+		if !matched && spec != "." {
+			return fmt.Errorf("nothing staged matches: %s", spec)
+		}
+	}
+
+	if len(unstaged) == 0 {
+		successf("✔ nothing staged")
+		return nil
+	}
+	if err := index.Save(indexPath, idx); err != nil {
+		return err
+	}
+	if len(unstaged) == 1 {
+		fmt.Println(c(ansiGreen, "✔ unstaged ") + c(ansiBoldCyan, unstaged[0]))
+		return nil
+	}
+	fmt.Println(c(ansiGreen, "✔ unstaged ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(unstaged))) + c(ansiGreen, " file(s)"))
+	return nil
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/objects"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+)
+
+const restoreUsage = "usage: sentra restore <project>[/<file>] --at <commit>"
+
+// restoredFile is one file's contents at the requested commit. Rel is the
+// full path ("root/.env").
+type restoredFile struct {
+	Rel     string
+	Content []byte
+}
+
+// sentra restore <project>[/<file>] --at <commit>
+func runRestore(args []string) error {
+	target, at, err := parseRestoreArgs(args)
+	if err != nil {
+		return err
+	}
+	scanRoot, err := resolveScanRoot()
+	if err != nil {
+		return err
+	}
+	verbosef("Scan root: %s", scanRoot)
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		return err
+	}
+	root := ids.rootOf(target)
+	if !isDir(filepath.Join(scanRoot, filepath.FromSlash(root))) {
+		return fmt.Errorf("project directory not found: %s", filepath.Join(scanRoot, filepath.FromSlash(root)))
+	}
+
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+
+	files, label, remoteAt, err := restoreFromLocal(ids, commits, root, target, at)
+	if err != nil {
+		return err
+	}
+	if remoteAt != "" {
+		files, err = restoreFromRemote(ids.remoteID(root), root, target, remoteAt)
+		if err != nil {
+			return err
+		}
+		label = at
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files for %s at %s", target, label)
+	}
+
+	backups := fileBackups{}
+	hashes := make(map[string]string, len(files))
+	written := 0
+	for _, f := range files {
+		outPath := filepath.Join(scanRoot, filepath.FromSlash(f.Rel))
+		hashes[f.Rel] = scanner.HashEnv(ids.relInProject(f.Rel), f.Content)
+
+		current, err := os.ReadFile(outPath)
+		if err == nil && string(current) == string(f.Content) {
+			verbosef("Unchanged: %s", f.Rel)
+			continue
+		}
+		if err := backups.save(scanRoot, f.Rel); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", f.Rel, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outPath, f.Content, 0o600); err != nil {
+			return err
+		}
+		written++
+		verbosef("Restored %s", f.Rel)
+	}
+
+	if err := updateBaseline(scanRoot, hashes, nil, false); err != nil {
+		return err
+	}
+
+	if written == 0 {
+		successf("✔ %s already matches %s", target, label)
+		return nil
+	}
+	successf("✔ restored %d file(s) of %s from %s", written, target, label)
+	if backups.count > 0 {
+		infof("Previous versions saved to %s", backups.dir)
+	}
+	return nil
+}
+
+func parseRestoreArgs(args []string) (target string, at string, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--at":
+			if i+1 >= len(args) || at != "" {
+				return "", "", errors.New(restoreUsage)
+			}
+			i++
+			at = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--at="):
+			if at != "" {
+				return "", "", errors.New(restoreUsage)
+			}
+			at = strings.TrimSpace(strings.TrimPrefix(a, "--at="))
+		case strings.HasPrefix(a, "-") || target != "":
+			return "", "", errors.New(restoreUsage)
+		default:
+			target = normalizeRelPath(a)
+		}
+	}
+	if target == "" || target == "." || strings.HasPrefix(target, "../") || at == "" {
+		return "", "", errors.New(restoreUsage)
+	}
+	return target, at, nil
+}
+
+// restoreFromLocal rebuilds the files under target as of a local commit,
+// replaying the project's commits up to it. If the files must come from the
+// remote instead, remoteAt is the commit id to fetch: at matches no local
+// commit, or the commit was pushed before snapshots were kept.
+func restoreFromLocal(ids *projectIDs, commits []commit.Commit, root string, target string, at string) (files []restoredFile, label string, remoteAt string, err error) {
+	id, err := resolveCommitID(commits, at)
+	if err != nil {
+		if errors.Is(err, errCommitNotFound) {
+			verbosef("Commit %s not found locally, fetching from remote", at)
+			return nil, "", at, nil
+		}
+		return nil, "", "", err
+	}
+
+	var sel commit.Commit
+	objs := map[string]string{}
+	// commit.List is oldest first; later commits overwrite earlier ones.
+	for _, cm := range commits {
+		for p := range cm.Files {
+			if matchesDiffTarget(p, target) {
+				objs[p], _ = cm.ObjectID(p)
+			}
+		}
+		for _, p := range cm.Deleted {
+			delete(objs, p)
+		}
+		if cm.ID == id {
+			sel = cm
+			break
+		}
+	}
+	label = shortCommitID(sel)
+	if !commitTouchesRoot(ids, sel, root) {
+		return nil, "", "", fmt.Errorf("commit %s has no files in %s", label, root)
+	}
+
+	files = make([]restoredFile, 0, len(objs))
+	for _, p := range sortedKeys(objs) {
+		if objs[p] == "" {
+			if strings.TrimSpace(sel.PushedAt) != "" {
+				verbosef("%s was committed before snapshots were kept, using the remote copy", p)
+				return nil, "", sel.ID, nil
+			}
+			return nil, "", "", fmt.Errorf("%s was committed before snapshots were kept and cannot be restored", p)
+		}
+		b, err := objects.Get(objs[p])
+		if err != nil {
+			return nil, "", "", fmt.Errorf("%s: %w", p, err)
+		}
+		files = append(files, restoredFile{Rel: p, Content: b})
+	}
+	return files, label, "", nil
+}
+
+// restoreFromRemote downloads and decrypts the files under target at a
+// commit of remote project id, checked out at root. Files deleted at that
+// commit are skipped.
+func restoreFromRemote(id string, root string, target string, at string) ([]restoredFile, error) {
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(sess.AccessToken) == "" {
+		return nil, errors.New("not logged in (run: sentra login)")
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	sp := startSpinner(fmt.Sprintf("Fetching %s at %s...", root, at))
+	files, err := fetchRemoteExportAt(serverURL, sess.AccessToken, id, at)
+	if err != nil {
+		sp.StopInfo("")
+		return nil, err
+	}
+
+	var out []restoredFile
+	for _, f := range files {
+		rel, ok := localPath(id, root, normalizeRelPath(strings.TrimSpace(f.Path)))
+		if !ok {
+			sp.StopInfo("")
+			return nil, fmt.Errorf("unexpected file path received from server")
+		}
+		if f.Deleted || !matchesDiffTarget(rel, target) {
+			continue
+		}
+		plain, err := decryptRemoteExportFile(id, f)
+		if err != nil {
+			sp.StopInfo("")
+			return nil, err
+		}
+		out = append(out, restoredFile{Rel: rel, Content: plain})
+	}
+	sp.StopInfo("")
+	return out, nil
+}
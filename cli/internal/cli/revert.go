@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/objects"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+)
+
+const revertUsage = "usage: sentra revert <commit> [--project <project>] [--to]"
+
+// sentra revert <commit> [--project <project>] [--to]
+//
+// Builds a new commit that puts the files changed by <commit> back to how
+// they were before it (or, with --to, the whole project back to how it was
+// at <commit>) and pushes it. Remote history is never rewritten.
+func runRevert(args []string) error {
+	selector, project, to, err := parseRevertArgs(args)
+	if err != nil {
+		return err
+	}
+	ids, err := currentProjectIDs()
+	if err != nil {
+		return err
+	}
+	var id, root string
+	if project != "" {
+		if id, root = ids.resolveProject(project); root == "" {
+			// Not checked out here: only a folder-named project maps to paths.
+			if strings.Contains(id, "/") {
+				return fmt.Errorf("project %s is not checked out under the scan root", id)
+			}
+			root = id
+		}
+	}
+
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+
+	// A local commit can name the project and carry the id the remote knows
+	// it by. Unpushed ones have nothing to revert on the remote.
+	var local *commit.Commit
+	if cid, err := resolveCommitID(commits, selector); err == nil {
+		for i := range commits {
+			if commits[i].ID == cid {
+				local = &commits[i]
+			}
+		}
+		if strings.TrimSpace(local.PushedAt) == "" {
+			short := shortCommitID(*local)
+			return fmt.Errorf("commit %s has not been pushed; drop it instead (sentra log rm %s)", short, short)
+		}
+		if root == "" {
+			roots := map[string]struct{}{}
+			for _, p := range local.Paths() {
+				roots[ids.rootOf(p)] = struct{}{}
+			}
+			if len(roots) != 1 {
+				return fmt.Errorf("commit %s touches %d projects; pick one with --project", shortCommitID(*local), len(roots))
+			}
+			root = sortedKeys(roots)[0]
+		}
+	} else if !errors.Is(err, errCommitNotFound) {
+		return err
+	}
+	if root == "" {
+		return errors.New("commit not found locally; name its project with --project")
+	}
+	if id == "" {
+		id = ids.remoteID(root)
+	}
+	verbosef("Project root: %s (id %s)", root, id)
+
+	// Push sends every pending commit, so with none the revert goes alone.
+	for _, cm := range commits {
+		if strings.TrimSpace(cm.PushedAt) == "" {
+			return errors.New("there are unpushed commits; run sentra push first")
+		}
+	}
+
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(sess.AccessToken) == "" {
+		return errors.New("not logged in (run: sentra login)")
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+
+	sp := startSpinner(fmt.Sprintf("Fetching %s history...", root))
+	history, err := fetchRemoteCommits(serverURL, sess.AccessToken, id)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	pos, err := findRemoteCommit(history, selector, local)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	if pos < 0 {
+		sp.StopInfo("")
+		return fmt.Errorf("commit not found on the remote: %s", selector)
+	}
+	target := history[pos]
+	targetID := strings.TrimSpace(target.CommitID)
+	verbosef("Reverting remote commit %s (%s)", targetID, oneLine(target.Message))
+
+	// The contents to go back to: the project at the commit, or at the one
+	// before it. Reverting a project's first commit goes back to no files.
+	sp.Set("Fetching contents...")
+	want := map[string][]byte{}
+	switch {
+	case to:
+		want, err = fetchProjectState(serverURL, sess.AccessToken, id, root, targetID)
+	case pos > 0:
+		want, err = fetchProjectState(serverURL, sess.AccessToken, id, root, strings.TrimSpace(history[pos-1].CommitID))
+	}
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	head, err := fetchProjectState(serverURL, sess.AccessToken, id, root, "")
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	projects, err := fetchRemoteProjects(serverURL, sess.AccessToken)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	sp.StopInfo("")
+
+	var scope []string
+	if to {
+		scope = unionKeys(sortedKeys(want), sortedKeys(head))
+	} else {
+		var touched []string
+		for _, p := range target.FilePaths {
+			if lp, ok := localPath(id, root, normalizeRelPath(strings.TrimSpace(p))); ok {
+				touched = append(touched, lp)
+			}
+		}
+		if local != nil {
+			touched = append(touched, local.Paths()...)
+		}
+		for _, p := range touched {
+			if p = normalizeRelPath(strings.TrimSpace(p)); ids.rootOf(p) == root {
+				scope = append(scope, p)
+			}
+		}
+		scope = unionKeys(scope)
+	}
+
+	writes := map[string][]byte{}
+	var deleted []string
+	for _, p := range scope {
+		w, inWant := want[p]
+		h, inHead := head[p]
+		switch {
+		case inWant && (!inHead || string(w) != string(h)):
+			writes[p] = w
+		case !inWant && inHead:
+			deleted = append(deleted, p)
+		}
+	}
+	short := targetID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+	if len(writes) == 0 && len(deleted) == 0 {
+		successf("✔ nothing to revert: %s already matches", root)
+		return nil
+	}
+
+	files := make(map[string]string, len(writes))
+	snapshots := make(map[string]string, len(writes))
+	for _, p := range sortedKeys(writes) {
+		objID, err := objects.Put(writes[p])
+		if err != nil {
+			return fmt.Errorf("cannot store %s in object store: %w", p, err)
+		}
+		files[p] = scanner.HashEnv(ids.relInProject(p), writes[p])
+		snapshots[p] = objID
+	}
+
+	msg := fmt.Sprintf("Revert %q (%s)", oneLine(target.Message), targetID)
+	if to {
+		msg = fmt.Sprintf("Restore %s to %q (%s)", root, oneLine(target.Message), targetID)
+	}
+	cm := commit.New(msg, files, snapshots)
+	cm.Deleted = deleted
+	parents, err := commitParents(cm.Paths())
+	if err != nil {
+		return err
+	}
+	// Chain onto the head the contents were compared against.
+	for _, p := range projects {
+		if strings.TrimSpace(p.RootPath) == id && strings.TrimSpace(p.LastClientID) != "" {
+			parents[root] = strings.TrimSpace(p.LastClientID)
+		}
+	}
+	cm.Parents = parents
+	if _, err := commit.Save(cm); err != nil {
+		return err
+	}
+	infof("Reverting %s: %d file(s) changed, %d deleted", short, len(writes), len(deleted))
+	fmt.Println(c(ansiGreen, "✔ committed ") + c(ansiBoldCyan, cm.ID[:8]) + " " + c(ansiDim, msg))
+
+	// The working copy follows only once the remote has the revert; until
+	// then it still matches what was last synced.
+	if err := runPush(); err != nil {
+		return fmt.Errorf("%w (the revert is committed; run sentra push, or drop it with sentra log rm %s)", err, shortCommitID(cm))
+	}
+	if err := applyRevertLocally(root, writes, deleted); err != nil {
+		warnf("Working copy not updated: %v", err)
+	}
+	return nil
+}
+
+func parseRevertArgs(args []string) (selector string, project string, to bool, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--to":
+			to = true
+		case a == "--project":
+			if i+1 >= len(args) || project != "" {
+				return "", "", false, errors.New(revertUsage)
+			}
+			i++
+			project = strings.TrimSpace(args[i])
+		case strings.HasPrefix(a, "--project="):
+			if project != "" {
+				return "", "", false, errors.New(revertUsage)
+			}
+			project = strings.TrimSpace(strings.TrimPrefix(a, "--project="))
+		case strings.HasPrefix(a, "-") || selector != "":
+			return "", "", false, errors.New(revertUsage)
+		default:
+			selector = strings.TrimSpace(a)
+		}
+	}
+	if selector == "" {
+		return "", "", false, errors.New(revertUsage)
+	}
+	return selector, project, to, nil
+}
+
+// findRemoteCommit sorts history oldest first and returns the position of
+// the commit matching selector (a remote id or prefix) or local, or -1.
+func findRemoteCommit(history []remoteCommit, selector string, local *commit.Commit) (int, error) {
+	sort.SliceStable(history, func(i, j int) bool {
+		return remoteCommitTime(history[i]).Before(remoteCommitTime(history[j]))
+	})
+	match := -1
+	for i, rc := range history {
+		id := strings.TrimSpace(rc.CommitID)
+		hit := strings.HasPrefix(id, selector)
+		if local != nil {
+			hit = hit || id == local.ID || strings.TrimSpace(rc.ClientID) == local.ID
+		}
+		if !hit {
+			continue
+		}
+		if match >= 0 {
+			return -1, fmt.Errorf("commit selector is ambiguous: %s", selector)
+		}
+		match = i
+	}
+	return match, nil
+}
+
+func remoteCommitTime(rc remoteCommit) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(rc.CreatedAt))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// fetchProjectState downloads and decrypts the files of project id at commit
+// at (the head if empty), keyed by their path under the local root.
+// Tombstones are left out.
+func fetchProjectState(serverURL string, accessToken string, id string, root string, at string) (map[string][]byte, error) {
+	files, err := fetchRemoteExportAt(serverURL, accessToken, id, at)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(files))
+	for _, f := range files {
+		p, ok := localPath(id, root, normalizeRelPath(strings.TrimSpace(f.Path)))
+		if !ok {
+			return nil, fmt.Errorf("unexpected file path received from server")
+		}
+		if f.Deleted {
+			continue
+		}
+		plain, err := decryptRemoteExportFile(id, f)
+		if err != nil {
+			return nil, err
+		}
+		out[p] = plain
+	}
+	return out, nil
+}
+
+// applyRevertLocally mirrors a revert in the working copy, backing up what it
+// replaces, so the next sync sees no local edits. Projects not checked out
+// on this machine are left alone.
+func applyRevertLocally(root string, writes map[string][]byte, deleted []string) error {
+	scanRoot, err := resolveScanRoot()
+	if err != nil {
+		return err
+	}
+	if !isDir(filepath.Join(scanRoot, filepath.FromSlash(root))) {
+		verbosef("%s is not checked out under %s; working copy left alone", root, scanRoot)
+		return nil
+	}
+
+	backups := fileBackups{}
+	synced := map[string]string{}
+	for _, p := range sortedKeys(writes) {
+		outPath := filepath.Join(scanRoot, filepath.FromSlash(p))
+		if err := backups.save(scanRoot, p); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", p, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outPath, writes[p], 0o600); err != nil {
+			return err
+		}
+		id, err := objects.Put(writes[p])
+		if err != nil {
+			return err
+		}
+		synced[p] = id
+	}
+	for _, p := range deleted {
+		if err := backups.save(scanRoot, p); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", p, err)
+		}
+		if err := os.Remove(filepath.Join(scanRoot, filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		synced[p] = ""
+	}
+	if err := commit.SetSynced(synced); err != nil {
+		verbosef("Failed to record synced files for %s: %v", root, err)
+	}
+	if backups.count > 0 {
+		infof("Previous versions saved to %s", backups.dir)
+	}
+	return nil
+}
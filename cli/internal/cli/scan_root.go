@@ -2,13 +2,17 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/mgeovany/sentra/cli/internal/index"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
 )
 
 func resolveScanRoot() (string, error) {
@@ -105,3 +109,61 @@ func isDir(p string) bool {
 	}
 	return info.IsDir()
 }
+
+// noScanCache is set by --no-cache: the scan reads and hashes everything,
+// then rewrites the cache from what it found.
+var noScanCache bool
+
+func withoutFlag(args []string, flag string) ([]string, bool) {
+	out := make([]string, 0, len(args))
+	found := false
+	for _, a := range args {
+		if a == flag {
+			found = true
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, found
+}
+
+// scanProjects scans scanRoot with SENTRA_SCAN_WORKERS workers (one per CPU
+// by default). Ctrl-C stops the scan instead of waiting for it. Unchanged
+// directories and files are served from ~/.sentra/scan-cache.json.
+func scanProjects(scanRoot string) ([]scanner.Project, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := scanner.Options{}
+	if v := strings.TrimSpace(os.Getenv("SENTRA_SCAN_WORKERS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid SENTRA_SCAN_WORKERS: %q (want a positive number)", v)
+		}
+		opts.Workers = n
+		verbosef("Scanning with %d worker(s)", n)
+	}
+
+	cachePath, err := scanner.DefaultCachePath()
+	if err != nil {
+		verbosef("Scan cache disabled: %v", err)
+	} else if noScanCache {
+		verbosef("Ignoring scan cache %s", cachePath)
+		opts.Cache = scanner.NewCache(cachePath)
+	} else {
+		opts.Cache = scanner.OpenCache(cachePath)
+	}
+
+	projects, err := scanner.ScanContext(ctx, scanRoot, opts)
+	if errors.Is(err, context.Canceled) {
+		return nil, errors.New("scan interrupted")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Cache.Save(); err != nil {
+		verbosef("Failed to save scan cache: %v", err)
+	}
+	scannedProjects[filepath.Clean(scanRoot)] = projects
+	return projects, nil
+}
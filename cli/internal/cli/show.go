@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+	"github.com/mgeovany/sentra/cli/internal/commit"
+)
+
+const showUsage = "usage: sentra show <commit> [--show-values]"
+
+// showFile is one file of a commit as show prints it. Parent and Current are
+// the versions before and after the commit.
+type showFile struct {
+	Path    string
+	Deleted bool
+	Size    int
+	Hash    string
+	Parent  diffSide
+	Current diffSide
+}
+
+// sentra show <commit> [--show-values]
+func runShow(args []string) error {
+	selector, showValues, err := parseShowArgs(args)
+	if err != nil {
+		return err
+	}
+
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+	id, err := resolveCommitID(commits, selector)
+	if err == nil {
+		return showLocalCommit(commits, id, showValues)
+	}
+	if !errors.Is(err, errCommitNotFound) {
+		return err
+	}
+	verbosef("Commit %s not found locally, searching the remote", selector)
+	return showRemoteCommit(selector, showValues)
+}
+
+func parseShowArgs(args []string) (selector string, showValues bool, err error) {
+	for _, a := range args {
+		switch {
+		case a == "--show-values":
+			showValues = true
+		case strings.HasPrefix(a, "-") || selector != "":
+			return "", false, errors.New(showUsage)
+		default:
+			selector = strings.TrimSpace(a)
+		}
+	}
+	if selector == "" {
+		return "", false, errors.New(showUsage)
+	}
+	return selector, showValues, nil
+}
+
+func showLocalCommit(commits []commit.Commit, id string, showValues bool) error {
+	pos := 0
+	for i, cm := range commits {
+		if cm.ID == id {
+			pos = i
+		}
+	}
+	cm := commits[pos]
+	ids, err := currentProjectIDs()
+	if err != nil {
+		return err
+	}
+
+	machine, _ := os.Hostname()
+	if cfg, ok, err := auth.LoadConfig(); err == nil && ok && strings.TrimSpace(cfg.MachineID) != "" {
+		machine = strings.TrimSpace(machine + " " + strings.TrimSpace(cfg.MachineID))
+	}
+	pushed := c(ansiYellow, "not pushed")
+	if strings.TrimSpace(cm.PushedAt) != "" {
+		pushed = strings.TrimSpace(cm.PushedAt)
+	}
+
+	fmt.Println(c(ansiCyan, "commit ") + c(ansiBoldCyan, shortCommitID(cm)) + c(ansiDim, " ("+cm.ID+")"))
+	fmt.Println(c(ansiDim, "Machine: ") + machine + c(ansiDim, " (this machine)"))
+	fmt.Println(c(ansiDim, "Created: ") + strings.TrimSpace(cm.CreatedAt))
+	fmt.Println(c(ansiDim, "Pushed:  ") + pushed)
+	for _, root := range sortedKeys(cm.Parents) {
+		fmt.Println(c(ansiDim, "Parent:  ") + root + " " + c(ansiDim, cm.Parents[root]))
+	}
+	printShowMessage(cm.Message)
+
+	files := make([]showFile, 0, len(cm.Files)+len(cm.Deleted))
+	for _, p := range cm.Paths() {
+		f := showFile{Path: p, Parent: localParentSide(ids, commits[:pos], cm, p)}
+		if cm.Deletes(p) {
+			f.Deleted = true
+		} else {
+			objID, _ := cm.ObjectID(p)
+			f.Hash = cm.Files[p]
+			if objID != "" {
+				f.Hash = objID
+			}
+			f.Current = readObject(objID, "committed before snapshots were kept")
+			f.Size = len(f.Current.content)
+		}
+		files = append(files, f)
+	}
+	printShowFiles(files, showValues)
+	return nil
+}
+
+// localParentSide finds a file's version before cm in the earlier local
+// commits. Versions that only exist on the remote are reported unavailable.
+func localParentSide(ids *projectIDs, earlier []commit.Commit, cm commit.Commit, p string) diffSide {
+	for i := len(earlier) - 1; i >= 0; i-- {
+		prev := earlier[i]
+		if prev.Deletes(p) {
+			return diffSide{}
+		}
+		if _, ok := prev.Files[p]; ok {
+			id, _ := prev.ObjectID(p)
+			return readObject(id, "parent committed before snapshots were kept")
+		}
+	}
+	if strings.TrimSpace(cm.Parents[ids.rootOf(p)]) == "" {
+		return diffSide{}
+	}
+	return diffSide{ok: true, unavailable: "parent version is only on the remote"}
+}
+
+func showRemoteCommit(selector string, showValues bool) error {
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(sess.AccessToken) == "" {
+		return errors.New("not logged in (run: sentra login)")
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+
+	sp := startSpinner("Searching remote commits...")
+	projects, err := fetchRemoteProjects(serverURL, sess.AccessToken)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return strings.TrimSpace(projects[i].RootPath) < strings.TrimSpace(projects[j].RootPath)
+	})
+
+	var root string
+	var history []remoteCommit
+	pos := -1
+	for _, p := range projects {
+		r := strings.TrimSpace(p.RootPath)
+		if r == "" {
+			continue
+		}
+		sp.Set(fmt.Sprintf("Searching %s...", r))
+		h, err := fetchRemoteCommits(serverURL, sess.AccessToken, r)
+		if err != nil {
+			sp.StopInfo("")
+			return err
+		}
+		i, err := findRemoteCommit(h, selector, nil)
+		if err != nil {
+			sp.StopInfo("")
+			return err
+		}
+		if i < 0 {
+			continue
+		}
+		if pos >= 0 {
+			sp.StopInfo("")
+			return fmt.Errorf("commit selector is ambiguous: %s", selector)
+		}
+		root, history, pos = r, h, i
+	}
+	if pos < 0 {
+		sp.StopInfo("")
+		return fmt.Errorf("%w: %s", errCommitNotFound, selector)
+	}
+	rc := history[pos]
+	id := strings.TrimSpace(rc.CommitID)
+
+	sp.Set("Fetching files...")
+	current, err := fetchRemoteExportAt(serverURL, sess.AccessToken, root, id)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	var parent []remoteExportFile
+	if pos > 0 {
+		parent, err = fetchRemoteExportAt(serverURL, sess.AccessToken, root, strings.TrimSpace(history[pos-1].CommitID))
+		if err != nil {
+			sp.StopInfo("")
+			return err
+		}
+	}
+	byPath := func(list []remoteExportFile) map[string]remoteExportFile {
+		out := make(map[string]remoteExportFile, len(list))
+		for _, f := range list {
+			out[normalizeRelPath(strings.TrimSpace(f.Path))] = f
+		}
+		return out
+	}
+	currentFiles, parentFiles := byPath(current), byPath(parent)
+	side := func(files map[string]remoteExportFile, p string) diffSide {
+		f, ok := files[p]
+		if !ok || f.Deleted {
+			return diffSide{}
+		}
+		plain, err := decryptRemoteExportFile(root, f)
+		if err != nil {
+			return diffSide{ok: true, unavailable: err.Error()}
+		}
+		return diffSide{content: plain, ok: true}
+	}
+
+	paths := make([]string, 0, len(rc.FilePaths))
+	for _, p := range rc.FilePaths {
+		if p = normalizeRelPath(strings.TrimSpace(p)); p != "." {
+			paths = append(paths, p)
+		}
+	}
+	files := make([]showFile, 0, len(paths))
+	for _, p := range unionKeys(paths) {
+		f := showFile{Path: p, Parent: side(parentFiles, p)}
+		if cf, ok := currentFiles[p]; ok && !cf.Deleted {
+			f.Size, f.Hash = cf.Size, strings.TrimSpace(cf.SHA256)
+			f.Current = side(currentFiles, p)
+		} else {
+			f.Deleted = true
+		}
+		files = append(files, f)
+	}
+	sp.StopInfo("")
+
+	machine := strings.TrimSpace(rc.MachineName)
+	if mid := strings.TrimSpace(rc.MachineID); mid != "" {
+		machine = strings.TrimSpace(machine + " " + mid)
+	}
+	if machine == "" {
+		machine = "unknown"
+	}
+	fmt.Println(c(ansiCyan, "commit ") + c(ansiBoldCyan, id))
+	fmt.Println(c(ansiDim, "Project: ") + root)
+	fmt.Println(c(ansiDim, "Machine: ") + machine)
+	fmt.Println(c(ansiDim, "Created: ") + strings.TrimSpace(rc.CreatedAt))
+	if pos > 0 {
+		fmt.Println(c(ansiDim, "Parent:  ") + strings.TrimSpace(history[pos-1].CommitID))
+	}
+	printShowMessage(rc.Message)
+	printShowFiles(files, showValues)
+	return nil
+}
+
+func printShowMessage(msg string) {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		msg = "(no message)"
+	}
+	fmt.Println(c(ansiDim, "Message:"))
+	fmt.Printf("  %s\n", msg)
+}
+
+// printShowFiles lists each file with its size and hash, then its key changes
+// against the parent. Values stay masked unless showValues is set.
+func printShowFiles(files []showFile, showValues bool) {
+	fmt.Println()
+	fmt.Println(c(ansiDim, "Files:"))
+	for _, f := range files {
+		switch {
+		case f.Deleted:
+			fmt.Println("  " + f.Path + "  " + c(ansiRed, "deleted"))
+		case f.Current.unavailable != "":
+			fmt.Println("  " + f.Path + "  " + c(ansiDim, "sha256 "+f.Hash))
+		default:
+			fmt.Println("  " + f.Path + "  " + c(ansiDim, fmt.Sprintf("%d bytes  sha256 %s", f.Size, f.Hash)))
+		}
+	}
+
+	for _, f := range files {
+		fmt.Println()
+		fmt.Println(c(ansiBold, f.Path))
+		o, n := f.Parent, f.Current
+		switch {
+		case o.unavailable != "":
+			fmt.Println("  " + c(ansiYellow, "? cannot show changes: "+o.unavailable))
+			continue
+		case n.unavailable != "":
+			fmt.Println("  " + c(ansiYellow, "? cannot show changes: "+n.unavailable))
+			continue
+		case !o.ok && n.ok:
+			fmt.Println("  " + c(ansiGreen, "new file"))
+		case !n.ok:
+			fmt.Println("  " + c(ansiRed, "deleted"))
+		}
+		changes := diffEnvKeys(o.content, n.content)
+		if len(changes) == 0 {
+			fmt.Println("  " + c(ansiDim, "no key changes"))
+			continue
+		}
+		for _, ch := range changes {
+			fmt.Println("  " + formatKeyChange(ch, showValues))
+		}
+	}
+}
@@ -2,11 +2,63 @@ package cli
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/mgeovany/sentra/cli/internal/scanner"
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/index"
 	"github.com/mgeovany/sentra/cli/internal/state"
 )
 
+type fileStatus int
+
+const (
+	statusInSync fileStatus = iota
+	statusUntracked
+	statusModified
+	statusStaged
+	statusCommitted
+	statusDeleted
+)
+
+func (s fileStatus) String() string {
+	switch s {
+	case statusUntracked:
+		return "untracked"
+	case statusModified:
+		return "modified"
+	case statusStaged:
+		return "staged"
+	case statusCommitted:
+		return "committed"
+	case statusDeleted:
+		return "deleted"
+	default:
+		return "in sync"
+	}
+}
+
+func (s fileStatus) color() ansiCode {
+	switch s {
+	case statusUntracked:
+		return ansiRed
+	case statusModified, statusDeleted:
+		return ansiYellow
+	case statusStaged, statusCommitted:
+		return ansiGreen
+	default:
+		return ansiDim
+	}
+}
+
+type fileStatusEntry struct {
+	Path   string
+	Status fileStatus
+	// Note qualifies the status, e.g. a staged file edited again since add.
+	Note string
+}
+
 func runStatus() error {
 	verbosef("Checking status...")
 	scanRoot, err := resolveScanRoot()
@@ -26,76 +78,223 @@ func runStatus() error {
 		return err
 	}
 	if !ok {
-		prev = state.State{ScanRoot: scanRoot, Projects: map[string]map[string]string{}, Version: 1}
 		verbosef("No previous state found, starting fresh")
 	} else {
 		verbosef("Loaded previous state with %d project(s)", len(prev.Projects))
 	}
 
 	verbosef("Scanning current state...")
-	currentProjects, err := scanner.Scan(scanRoot)
+	currentProjects, err := scanProjects(scanRoot)
 	if err != nil {
 		return err
 	}
 	verbosef("Found %d current project(s)", len(currentProjects))
-	curr, err := state.FromScan(scanRoot, currentProjects)
+
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		return err
+	}
+	idx, _, err := index.Load(indexPath)
+	if err != nil {
+		return err
+	}
+	commits, err := commit.List()
 	if err != nil {
 		return err
 	}
-	verbosef("Current state has %d project(s)", len(curr.Projects))
 
-	changed := countChangedEnvFiles(prev, curr)
-	verbosef("Changed files detected: %d", changed)
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println(c(ansiGreen, "✔ ") + c(ansiBoldCyan, fmt.Sprintf("%d", len(prev.Projects))) + c(ansiGreen, " projects tracked"))
-	if changed == 0 {
-		fmt.Println(c(ansiGreen, "✔ ") + c(ansiBoldCyan, "0") + c(ansiGreen, " env changed"))
-		verbosef("All env files are up to date")
+	pending, pendingDeleted := pendingCommitHashes(commits)
+	entries := computeFileStatuses(statusInputs{
+		Working:        flattenScan(scanRoot, currentProjects),
+		Staged:         idx.Staged,
+		StagedDeleted:  stringSet(idx.Deleted),
+		Pending:        pending,
+		PendingDeleted: pendingDeleted,
+		Baseline:       prev.Files(),
+	})
+	if len(entries) == 0 {
+		infof("No env files found under %s", scanRoot)
 		return nil
 	}
-	fmt.Println(c(ansiYellow, "⚠ ") + c(ansiBoldCyan, fmt.Sprintf("%d", changed)) + c(ansiYellow, " env changed"))
-	verbosef("Run 'sentra add .' to stage changed files")
 
+	// Group by checkout: a nested project's files sort among its parent's.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return ids.dirOf(entries[i].Path) < ids.dirOf(entries[j].Path)
+	})
+	counts := map[fileStatus]int{}
+	lastRoot := ""
+	for _, e := range entries {
+		counts[e.Status]++
+		root := ids.dirOf(e.Path)
+		if root != lastRoot {
+			if lastRoot != "" {
+				fmt.Println()
+			}
+			fmt.Println(c(ansiBold, root))
+			lastRoot = root
+		}
+		label := fmt.Sprintf("%-11s", e.Status.String()+":")
+		line := "  " + c(e.Status.color(), label) + " " + ids.relInProject(e.Path)
+		if e.Note != "" {
+			line += " " + c(ansiDim, "("+e.Note+")")
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	var parts []string
+	for _, s := range []fileStatus{statusInSync, statusModified, statusUntracked, statusStaged, statusCommitted, statusDeleted} {
+		if n := counts[s]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, s))
+		}
+	}
+	infof("%s", strings.Join(parts, ", "))
+
+	switch {
+	case counts[statusModified]+counts[statusUntracked] > 0:
+		verbosef("Run 'sentra add .' to stage changed files")
+	case counts[statusStaged] > 0:
+		verbosef("Run 'sentra commit -m ...' to commit staged files")
+	case counts[statusCommitted] > 0:
+		verbosef("Run 'sentra push' to push pending commits")
+	}
 	return nil
 }
 
-func countChangedEnvFiles(prev state.State, curr state.State) int {
-	prevFiles := flattenEnv(prev)
-	currFiles := flattenEnv(curr)
+// statusInputs holds everything status compares. Maps are keyed by full path
+// ("root/.env") and hold scanner hashes.
+type statusInputs struct {
+	Working        map[string]string
+	Staged         map[string]string
+	StagedDeleted  map[string]bool
+	Pending        map[string]string
+	PendingDeleted map[string]bool
+	Baseline       map[string]string
+}
 
-	changed := 0
-	seen := map[string]struct{}{}
+// computeFileStatuses classifies every file known to the working copy, the
+// index, pending commits or the baseline.
+func computeFileStatuses(in statusInputs) []fileStatusEntry {
+	working, staged, pending, baseline := in.Working, in.Staged, in.Pending, in.Baseline
+	paths := unionKeys(sortedKeys(working), sortedKeys(staged), sortedKeys(pending), sortedKeys(baseline), sortedKeys(in.StagedDeleted), sortedKeys(in.PendingDeleted))
+	out := make([]fileStatusEntry, 0, len(paths))
+	for _, p := range paths {
+		w, inWorking := working[p]
+		e := fileStatusEntry{Path: p}
+		switch {
+		case !inWorking && in.StagedDeleted[p]:
+			e.Status = statusStaged
+			e.Note = "deletion"
+		case !inWorking && in.PendingDeleted[p]:
+			if baseline[p] == "" {
+				// Deletion already pushed; nothing left to report.
+				continue
+			}
+			e.Status = statusCommitted
+			e.Note = "deletion, not pushed"
+		case !inWorking:
+			e.Status = statusDeleted
+		case staged[p] != "":
+			e.Status = statusStaged
+			if staged[p] != w {
+				e.Note = "modified since add"
+			}
+		case pending[p] != "":
+			e.Status = statusCommitted
+			if pending[p] != w {
+				e.Status = statusModified
+			} else {
+				e.Note = "not pushed"
+			}
+		case baseline[p] == "":
+			e.Status = statusUntracked
+		case baseline[p] != w:
+			e.Status = statusModified
+		default:
+			e.Status = statusInSync
+		}
+		out = append(out, e)
+	}
+	return out
+}
 
-	for k, prevHash := range prevFiles {
-		seen[k] = struct{}{}
-		currHash, ok := currFiles[k]
-		if !ok {
-			changed++
+// pendingCommitHashes returns, per path, the hash in the newest unpushed
+// commit, and the paths whose newest unpushed change is a deletion.
+func pendingCommitHashes(commits []commit.Commit) (map[string]string, map[string]bool) {
+	hashes := map[string]string{}
+	deleted := map[string]bool{}
+	// commit.List is oldest first; later commits overwrite earlier ones.
+	for _, cm := range commits {
+		if strings.TrimSpace(cm.PushedAt) != "" {
 			continue
 		}
-		if currHash != prevHash {
-			changed++
+		for p, hash := range cm.Files {
+			hashes[p] = hash
+			delete(deleted, p)
+		}
+		for _, p := range cm.Deleted {
+			delete(hashes, p)
+			deleted[p] = true
 		}
 	}
+	return hashes, deleted
+}
 
-	for k := range currFiles {
-		if _, ok := seen[k]; ok {
-			continue
+// trackedPaths returns files sentra knows about: in the baseline or written
+// by a pending commit. Only these can be staged for deletion.
+func trackedPaths(commits []commit.Commit) (map[string]bool, error) {
+	statePath, err := state.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	st, _, err := state.Load(statePath)
+	if err != nil {
+		return nil, err
+	}
+	pending, pendingDeleted := pendingCommitHashes(commits)
+	out := map[string]bool{}
+	for p := range st.Files() {
+		if !pendingDeleted[p] {
+			out[p] = true
 		}
-		// new file
-		changed++
 	}
-
-	return changed
+	for p := range pending {
+		out[p] = true
+	}
+	return out, nil
 }
 
-func flattenEnv(s state.State) map[string]string {
-	out := make(map[string]string)
-	for projectRoot, envs := range s.Projects {
-		for envPath, hash := range envs {
-			key := projectRoot + ":" + envPath
-			out[key] = hash
-		}
+func stringSet(items []string) map[string]bool {
+	out := make(map[string]bool, len(items))
+	for _, s := range items {
+		out[s] = true
 	}
 	return out
 }
+
+// updateBaseline records files (full path -> scanner hash) as the known
+// version in state.json and drops deleted paths. pushed also stamps the push time.
+func updateBaseline(scanRoot string, files map[string]string, deleted []string, pushed bool) error {
+	statePath, err := state.DefaultPath()
+	if err != nil {
+		return err
+	}
+	st, _, err := state.Load(statePath)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(scanRoot) != "" {
+		st.ScanRoot = scanRoot
+	}
+	st.Record(files)
+	st.Forget(deleted)
+	if pushed {
+		st.PushedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return state.Save(statePath, st)
+}
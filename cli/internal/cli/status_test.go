@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+	"github.com/mgeovany/sentra/cli/internal/state"
+)
+
+func TestStatusRootLevelProject(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	// The scan root is itself the project.
+	if err := os.MkdirAll(filepath.Join(scanRoot, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(scanRoot, ".env"), "A=1\n")
+	writeTestFile(t, filepath.Join(scanRoot, "config", ".env"), "B=1\n")
+
+	projects, err := scanProjects(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	working := flattenScan(scanRoot, projects)
+	if len(working) != 2 || working[".env"] == "" {
+		t.Fatalf("scanned %v", working)
+	}
+	if err := updateBaseline(scanRoot, working, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	st := loadTestState(t)
+	for _, e := range computeFileStatuses(statusInputs{Working: working, Baseline: st.Files()}) {
+		if e.Status != statusInSync {
+			t.Errorf("%s: %s after recording it, want in sync", e.Path, e.Status)
+		}
+	}
+
+	if err := updateBaseline(scanRoot, nil, []string{".env"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadTestState(t).Files(); len(got) != 1 || got["config/.env"] == "" {
+		t.Fatalf("baseline after forgetting .env = %v", got)
+	}
+}
+
+func TestBaselineHashMatchesScan(t *testing.T) {
+	scanRoot := newTestLayout(t)
+
+	projects, err := scanProjects(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	working := flattenScan(scanRoot, projects)
+	if len(working) != 4 {
+		t.Fatalf("scanned %v", working)
+	}
+	for p, hash := range working {
+		b, err := os.ReadFile(filepath.Join(scanRoot, filepath.FromSlash(p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := scanner.HashEnv(ids.relInProject(p), b); got != hash {
+			t.Errorf("%s: baseline hash %s, scanned %s", p, got, hash)
+		}
+	}
+}
+
+func loadTestState(t *testing.T) state.State {
+	t.Helper()
+	p, err := state.DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, _, err := state.Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -16,14 +18,20 @@ import (
 	"time"
 
 	"github.com/mgeovany/sentra/cli/internal/auth"
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/objects"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
 	"github.com/mgeovany/sentra/cli/internal/storage"
 )
 
 // sentra sync
 // Downloads latest env files from remote and writes them into local repos under scan root.
+// Local edits are merged key by key with the remote version; conflicting keys
+// are resolved interactively or reported, and overwritten files are backed up.
 func runSync(args []string) error {
-	if len(args) != 0 {
-		return errors.New("usage: sentra sync")
+	opts, err := parseSyncArgs(args)
+	if err != nil {
+		return err
 	}
 
 	verbosef("Starting sync operation...")
@@ -48,12 +56,25 @@ func runSync(args []string) error {
 	}
 	verbosef("Server URL: %s", serverURL)
 
-	sp := startSpinner("Fetching projects from remote...")
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		return err
+	}
+
+	sp := opts.spinner("Fetching projects from remote...")
 	projects, err := fetchRemoteProjects(serverURL, sess.AccessToken)
 	if err != nil {
 		sp.StopInfo("")
 		return err
 	}
+	projects, err = selectSyncProjects(projects, opts, ids)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	if len(projects) == 0 && opts.JSON {
+		return printSyncPlan(syncPlan{DryRun: true, Projects: []syncPlanProject{}}, true)
+	}
 	if len(projects) == 0 {
 		sp.StopSuccess("✔ 0 projects")
 		fmt.Println("✔ 0 projects")
@@ -68,84 +89,458 @@ func runSync(args []string) error {
 		return strings.TrimSpace(projects[i].RootPath) < strings.TrimSpace(projects[j].RootPath)
 	})
 
-	written := 0
+	heads, err := commit.LoadHeads()
+	if err != nil {
+		return err
+	}
+	commits, err := commit.List()
+	if err != nil {
+		return err
+	}
+	interactive := isTTY(os.Stdin) && isTTY(os.Stdout)
+	backups := &fileBackups{}
+	plan := syncPlan{DryRun: true, Projects: []syncPlanProject{}}
+
+	var written, merged, keptLocal, conflicted, removed, declinedDeletes int
 	scanned := 0
 	skippedMissing := 0
-	sp2 := startSpinner("Syncing projects...")
+	sp2 := opts.spinner("Syncing projects...")
 	for i, p := range projects {
-		root := strings.TrimSpace(p.RootPath)
-		if root == "" {
+		id := strings.TrimSpace(p.RootPath)
+		if id == "" {
 			continue
 		}
-		sp2.Set(fmt.Sprintf("Syncing %s (%d/%d)...", root, i+1, len(projects)))
-		localRepo := filepath.Join(scanRoot, filepath.FromSlash(root))
-		verbosef("Checking local repo: %s", localRepo)
-		if !isDir(localRepo) {
-			verbosef("Skipping %s: local directory not found", root)
+		sp2.Set(fmt.Sprintf("Syncing %s (%d/%d)...", id, i+1, len(projects)))
+		// The remote project is checked out under whatever folder has its ID.
+		root, ok := ids.localRoot(id)
+		if !ok {
+			verbosef("Skipping %s: no local repository with this project id", id)
 			skippedMissing++
+			plan.Projects = append(plan.Projects, syncPlanProject{Root: id, ID: id, Status: "missing"})
 			continue
 		}
+		localRepo := filepath.Join(scanRoot, filepath.FromSlash(root))
+		verbosef("Checking local repo: %s (project %s)", localRepo, id)
 
-		verbosef("Fetching files for project: %s", root)
-		files, err := fetchRemoteExport(serverURL, sess.AccessToken, root)
+		verbosef("Fetching files for project: %s", id)
+		files, err := fetchRemoteExportAt(serverURL, sess.AccessToken, id, opts.At)
 		if err != nil {
 			sp2.StopInfo("")
 			return err
 		}
+		files = filterSyncFiles(files, id, opts)
 		if len(files) == 0 {
-			verbosef("No files found for project: %s", root)
+			verbosef("No files found for project: %s", id)
+			plan.Projects = append(plan.Projects, syncPlanProject{Root: root, ID: id, Status: "empty"})
 			continue
 		}
-		verbosef("Found %d file(s) for project: %s", len(files), root)
+		verbosef("Found %d file(s) for project: %s", len(files), id)
 
 		scanned++
-		for _, f := range files {
-			verbosef("Processing file: %s (size: %d bytes, cipher: %s)", f.Path, f.Size, f.Cipher)
-			plain, err := decryptRemoteExportFile(f)
-			if err != nil {
+		// Decrypt, verify and merge the whole project before touching the working copy.
+		actions, err := planProjectSync(scanRoot, id, root, files, heads, commits)
+		if err != nil {
+			sp2.StopInfo("")
+			return err
+		}
+		if opts.DryRun {
+			plan.Projects = append(plan.Projects, planProject(root, id, actions))
+			continue
+		}
+
+		blocked := false
+		for j := range actions {
+			a := &actions[j]
+			if a.Kind != syncConflict {
+				continue
+			}
+			if !interactive {
 				sp2.StopInfo("")
+				printConflicts(a.Rel, a.Conflicts)
+				blocked = true
+				continue
+			}
+			sp2.StopInfo("")
+			resolve, err := resolveConflictsInteractive(a.Rel, a.Conflicts)
+			if err != nil {
 				return err
 			}
-			verbosef("Decrypted file: %s (%d bytes)", f.Path, len(plain))
+			a.Result, _ = mergeEnv(a.Base, a.Local, a.Remote, resolve)
+			a.Kind = syncMerge
+			sp2 = opts.spinner(fmt.Sprintf("Syncing %s (%d/%d)...", root, i+1, len(projects)))
+		}
+		if blocked {
+			conflicted++
+			warnf("⚠ %s not synced: local and remote changed the same keys", root)
+			continue
+		}
 
-			// Server returns full file path (e.g. "root/.env"); write into scanRoot.
-			rel := filepath.ToSlash(strings.TrimSpace(f.Path))
-			if rel == "" || strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, "\\") {
-				return fmt.Errorf("invalid file path received from server")
+		// Files deleted on the remote are only removed after confirmation.
+		var deletions []string
+		for _, a := range actions {
+			if a.Kind == syncDelete {
+				deletions = append(deletions, a.Rel)
 			}
-			rel = strings.TrimPrefix(rel, "./")
-			rel = filepath.Clean(rel)
-			rel = filepath.ToSlash(rel)
-			if rel == "." || rel == "" || strings.HasPrefix(rel, "../") {
-				return fmt.Errorf("invalid file path received from server")
+		}
+		if len(deletions) > 0 && !opts.Yes {
+			sp2.StopInfo("")
+			confirmed, err := confirmSyncDeletions(root, deletions, interactive)
+			if err != nil {
+				return err
 			}
-			if !strings.HasPrefix(rel, root+"/") {
-				return fmt.Errorf("unexpected file path received from server")
+			if !confirmed {
+				declinedDeletes += len(deletions)
+				kept := actions[:0]
+				for _, a := range actions {
+					if a.Kind != syncDelete {
+						kept = append(kept, a)
+					}
+				}
+				actions = kept
 			}
+			sp2 = opts.spinner(fmt.Sprintf("Syncing %s (%d/%d)...", root, i+1, len(projects)))
+		}
 
-			outPath := filepath.Join(scanRoot, filepath.FromSlash(rel))
-			verbosef("Writing file to: %s", outPath)
-			if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-				sp2.StopInfo("")
-				return err
+		synced := map[string]string{}
+		baseline := map[string]string{}
+		var forgotten []string
+		for _, a := range actions {
+			switch a.Kind {
+			case syncKeepLocal:
+				keptLocal++
+			case syncMerge:
+				merged++
+			}
+			if a.Tombstone {
+				if a.Kind == syncDelete {
+					if err := backups.save(scanRoot, a.Rel); err != nil {
+						sp2.StopInfo("")
+						return fmt.Errorf("cannot back up %s: %w", a.Rel, err)
+					}
+					if err := os.Remove(a.OutPath); err != nil && !os.IsNotExist(err) {
+						sp2.StopInfo("")
+						return err
+					}
+					removed++
+					verbosef("Removed file deleted on remote: %s", a.OutPath)
+				}
+				synced[a.Rel] = ""
+				forgotten = append(forgotten, a.Rel)
+				continue
+			}
+			if a.writes() {
+				if err := backups.save(scanRoot, a.Rel); err != nil {
+					sp2.StopInfo("")
+					return fmt.Errorf("cannot back up %s: %w", a.Rel, err)
+				}
+				verbosef("Writing file to: %s", a.OutPath)
+				if err := os.MkdirAll(filepath.Dir(a.OutPath), 0o755); err != nil {
+					sp2.StopInfo("")
+					return err
+				}
+				if err := os.WriteFile(a.OutPath, a.Result, 0o600); err != nil {
+					sp2.StopInfo("")
+					return err
+				}
+				written++
+				verbosef("Successfully wrote file: %s", a.OutPath)
 			}
-			if err := os.WriteFile(outPath, plain, 0o600); err != nil {
+			// The remote version is now part of the working copy: it is the
+			// base for the next merge.
+			id, err := objects.Put(a.Remote)
+			if err != nil {
 				sp2.StopInfo("")
-				return err
+				return fmt.Errorf("cannot store %s in object store: %w", a.Rel, err)
+			}
+			synced[a.Rel] = id
+			baseline[a.Rel] = scanner.HashEnv(ids.relInProject(a.Rel), a.Remote)
+		}
+		if err := commit.SetSynced(synced); err != nil {
+			verbosef("Failed to record synced files for %s: %v", root, err)
+		}
+		if err := updateBaseline(scanRoot, baseline, forgotten, false); err != nil {
+			verbosef("Failed to update status baseline for %s: %v", root, err)
+		}
+
+		// The working copy now includes the remote head; chain new commits onto it.
+		// An older commit (--at) says nothing about the head.
+		if head := strings.TrimSpace(p.LastClientID); head != "" && opts.At == "" {
+			if err := commit.SetHead(id, head); err != nil {
+				verbosef("Failed to record remote head for %s: %v", root, err)
 			}
-			written++
-			verbosef("Successfully wrote file: %s", outPath)
 		}
 	}
+	if opts.DryRun {
+		sp2.StopInfo("")
+		return printSyncPlan(plan, opts.JSON)
+	}
 	sp2.StopSuccess(fmt.Sprintf("✔ synced %d env file(s) across %d project(s)", written, scanned))
+	if removed > 0 {
+		infof("%d file(s) deleted on the remote were removed", removed)
+	}
+	if declinedDeletes > 0 {
+		warnf("⚠ %d file(s) deleted on the remote were kept (run: sentra sync --yes to remove them)", declinedDeletes)
+	}
+	if merged > 0 {
+		infof("%d file(s) merged with local changes (run: sentra add . && sentra commit to share them)", merged)
+	}
+	if keptLocal > 0 {
+		infof("%d file(s) have unpushed local changes and were left as is", keptLocal)
+	}
+	if backups.count > 0 {
+		infof("Previous versions of %d file(s) backed up to %s", backups.count, backups.dir)
+	}
 	if skippedMissing > 0 {
 		warnf("⚠ %d project(s) missing locally under %s", skippedMissing, scanRoot)
-		verbosef("Missing projects were skipped (not found in scan root)")
+		verbosef("Missing projects were skipped (no repository under the scan root has their id)")
 	}
 	verbosef("Sync completed: %d file(s) written, %d project(s) synced, %d skipped", written, scanned, skippedMissing)
+	if conflicted > 0 {
+		return fmt.Errorf("sync: %d project(s) have conflicts; resolve them in a terminal, or edit the files and push first", conflicted)
+	}
 	return nil
 }
 
+type syncActionKind int
+
+const (
+	syncUnchanged syncActionKind = iota
+	syncCreate
+	syncUpdate
+	syncMerge
+	syncKeepLocal
+	syncConflict
+	syncDelete
+)
+
+func (k syncActionKind) String() string {
+	switch k {
+	case syncCreate:
+		return "create"
+	case syncUpdate:
+		return "update"
+	case syncMerge:
+		return "merge"
+	case syncKeepLocal:
+		return "keep local"
+	case syncConflict:
+		return "conflict"
+	case syncDelete:
+		return "delete"
+	default:
+		return "unchanged"
+	}
+}
+
+// syncAction is what sync will do with one remote file.
+type syncAction struct {
+	Rel     string
+	OutPath string
+	Kind    syncActionKind
+	Base    []byte
+	Local   []byte
+	Remote  []byte
+	// Result is what gets written for create, update and merge.
+	Result    []byte
+	Conflicts []keyConflict
+	// Tombstone is set when the remote deleted the file.
+	Tombstone bool
+}
+
+func (a syncAction) writes() bool {
+	return a.Kind == syncCreate || a.Kind == syncUpdate || a.Kind == syncMerge
+}
+
+// planProjectSync decrypts every remote file of project id and decides,
+// against the working copy under root and the last synced or pushed
+// version, how to apply it.
+func planProjectSync(scanRoot string, id string, root string, files []remoteExportFile, heads commit.Heads, commits []commit.Commit) ([]syncAction, error) {
+	actions := make([]syncAction, 0, len(files))
+	for _, f := range files {
+		verbosef("Processing file: %s (size: %d bytes, cipher: %s)", f.Path, f.Size, f.Cipher)
+
+		// Server returns the path under the project id (e.g. "github.com/org/api/.env");
+		// write it under the local root instead.
+		rel := filepath.ToSlash(strings.TrimSpace(f.Path))
+		if rel == "" || strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, "\\") {
+			return nil, fmt.Errorf("invalid file path received from server")
+		}
+		rel = strings.TrimPrefix(rel, "./")
+		rel = filepath.Clean(rel)
+		rel = filepath.ToSlash(rel)
+		if rel == "." || rel == "" || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("invalid file path received from server")
+		}
+		rel, ok := localPath(id, root, rel)
+		if !ok {
+			return nil, fmt.Errorf("unexpected file path received from server")
+		}
+		outPath := filepath.Join(scanRoot, filepath.FromSlash(rel))
+
+		if f.Deleted {
+			local, err := os.ReadFile(outPath)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			// Remove the file only if it has no local edits since it was
+			// last synced or pushed; otherwise keep it.
+			a := syncAction{Rel: rel, OutPath: outPath, Kind: syncDelete, Local: local, Tombstone: true}
+			if base, ok := syncBase(rel, heads, commits); (ok && !bytes.Equal(local, base)) || committedNotPushed(rel, commits) {
+				a.Kind = syncKeepLocal
+				a.Result = local
+			}
+			verbosef("Plan for %s: %s (deleted on remote)", rel, a.Kind)
+			actions = append(actions, a)
+			continue
+		}
+
+		plain, err := decryptRemoteExportFile(id, f)
+		if err != nil {
+			return nil, err
+		}
+		verbosef("Decrypted and verified file: %s (%d bytes)", f.Path, len(plain))
+
+		a := syncAction{Rel: rel, OutPath: outPath, Remote: plain, Result: plain}
+		local, err := os.ReadFile(a.OutPath)
+		switch {
+		case os.IsNotExist(err):
+			a.Kind = syncCreate
+			actions = append(actions, a)
+			continue
+		case err != nil:
+			return nil, err
+		}
+		a.Local = local
+		if bytes.Equal(local, plain) {
+			a.Kind = syncUnchanged
+			actions = append(actions, a)
+			continue
+		}
+
+		base, hasBase := syncBase(rel, heads, commits)
+		a.Base = base
+		switch {
+		case hasBase && bytes.Equal(local, base):
+			a.Kind = syncUpdate
+		case hasBase && bytes.Equal(plain, base):
+			a.Kind = syncKeepLocal
+			a.Result = local
+		default:
+			a.Result, a.Conflicts = mergeEnv(base, local, plain, nil)
+			switch {
+			case len(a.Conflicts) > 0:
+				a.Kind = syncConflict
+			case bytes.Equal(a.Result, local):
+				a.Kind = syncKeepLocal
+			case bytes.Equal(a.Result, plain):
+				a.Kind = syncUpdate
+			default:
+				a.Kind = syncMerge
+			}
+		}
+		verbosef("Plan for %s: %s (base available: %v)", rel, a.Kind, hasBase)
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// committedNotPushed reports whether a pending commit has rel.
+func committedNotPushed(rel string, commits []commit.Commit) bool {
+	for _, cm := range commits {
+		if _, ok := cm.Files[rel]; ok && strings.TrimSpace(cm.PushedAt) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// syncBase returns the version the working copy and the remote last agreed
+// on: whichever is newer of what sync last wrote and what was last pushed.
+// Unpushed commits are local edits, not a base: taking one would make the
+// remote version look like the only change and overwrite it.
+func syncBase(rel string, heads commit.Heads, commits []commit.Commit) ([]byte, bool) {
+	var id string
+	var at time.Time
+	if sf, ok := heads.Synced[rel]; ok {
+		id = strings.TrimSpace(sf.Object)
+		at, _ = time.Parse(time.RFC3339Nano, sf.At)
+	}
+	// commit.List is oldest first; only a newer commit replaces the synced base.
+	for _, cm := range commits {
+		if strings.TrimSpace(cm.PushedAt) == "" {
+			continue
+		}
+		cid, ok := cm.ObjectID(rel)
+		if !ok || cm.Time().Before(at) {
+			continue
+		}
+		id, at = cid, cm.Time()
+	}
+	if id == "" {
+		return nil, false
+	}
+	b, err := objects.Get(id)
+	if err != nil {
+		verbosef("Merge base for %s unavailable: %v", rel, err)
+		return nil, false
+	}
+	return b, true
+}
+
+func confirmSyncDeletions(root string, paths []string, interactive bool) (bool, error) {
+	warnf("⚠ %d file(s) in %s were deleted on the remote:", len(paths), root)
+	for _, p := range paths {
+		fmt.Println("  " + c(ansiRed, "- "+p))
+	}
+	if !interactive {
+		return false, nil
+	}
+	return promptYesNo(bufio.NewReader(os.Stdin), "Delete them locally (a backup is kept)?", false)
+}
+
+// selectSyncProjects keeps the projects named on the command line and fails
+// on names the remote doesn't know, rather than silently syncing nothing.
+// A project is named by its ID or by its local folder.
+func selectSyncProjects(projects []remoteProject, opts syncOptions, ids *projectIDs) ([]remoteProject, error) {
+	if len(opts.Projects) == 0 {
+		return projects, nil
+	}
+	known := map[string]struct{}{}
+	out := make([]remoteProject, 0, len(opts.Projects))
+	for _, p := range projects {
+		id := strings.TrimSpace(p.RootPath)
+		known[id] = struct{}{}
+		root, local := ids.localRoot(id)
+		if local {
+			known[root] = struct{}{}
+		}
+		if opts.wantsProject(id) || (local && opts.wantsProject(root)) {
+			out = append(out, p)
+		}
+	}
+	for _, want := range opts.Projects {
+		if _, ok := known[want]; !ok {
+			return nil, fmt.Errorf("project not found on remote: %s (run: sentra projects)", want)
+		}
+	}
+	return out, nil
+}
+
+func filterSyncFiles(files []remoteExportFile, id string, opts syncOptions) []remoteExportFile {
+	out := files[:0]
+	for _, f := range files {
+		if opts.wantsFile(id, strings.TrimSpace(f.Path)) {
+			out = append(out, f)
+		} else {
+			verbosef("Skipping %s: filtered out", strings.TrimSpace(f.Path))
+		}
+	}
+	return out
+}
+
 func fetchRemoteProjects(serverURL string, accessToken string) ([]remoteProject, error) {
 	endpoint := strings.TrimRight(strings.TrimSpace(serverURL), "/") + "/projects"
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, endpoint, nil)
@@ -175,12 +570,20 @@ func fetchRemoteProjects(serverURL string, accessToken string) ([]remoteProject,
 }
 
 func fetchRemoteExport(serverURL string, accessToken string, root string) ([]remoteExportFile, error) {
+	return fetchRemoteExportAt(serverURL, accessToken, root, "")
+}
+
+// fetchRemoteExportAt lists a project's files at commit at (latest if empty).
+func fetchRemoteExportAt(serverURL string, accessToken string, root string, at string) ([]remoteExportFile, error) {
 	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(serverURL), "/") + "/export")
 	if err != nil {
 		return nil, err
 	}
 	q := u.Query()
 	q.Set("root", strings.TrimSpace(root))
+	if at = strings.TrimSpace(at); at != "" {
+		q.Set("at", at)
+	}
 	u.RawQuery = q.Encode()
 
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u.String(), nil)
@@ -210,17 +613,22 @@ func fetchRemoteExport(serverURL string, accessToken string, root string) ([]rem
 	return files, nil
 }
 
-func decryptRemoteExportFile(f remoteExportFile) ([]byte, error) {
+// decryptRemoteExportFile downloads (if stored in BYOS), decrypts and verifies
+// a remote file. Every path that writes remote plaintext to disk goes through
+// it, so nothing is written unless sha256 and size match what was pushed.
+func decryptRemoteExportFile(root string, f remoteExportFile) ([]byte, error) {
 	cipherName := strings.TrimSpace(f.Cipher)
 	blobB64 := strings.TrimSpace(f.BlobB64)
 	if blobB64 == "" && strings.TrimSpace(f.StorageKey) != "" {
+		verbosef("File stored in BYOS, downloading from storage: %s", f.StorageKey)
 		s3cfg, _, enabled, err := storage.ResolveS3()
 		if err != nil {
 			return nil, err
 		}
 		if !enabled {
-			return nil, fmt.Errorf("sync requires storage setup (run: sentra storage setup)")
+			return nil, fmt.Errorf("file %s is in your storage; run: sentra storage setup", strings.TrimSpace(f.Path))
 		}
+		// Prefer server-provided location if present.
 		if strings.TrimSpace(f.StorageBucket) != "" {
 			s3cfg.Bucket = strings.TrimSpace(f.StorageBucket)
 		}
@@ -230,6 +638,7 @@ func decryptRemoteExportFile(f remoteExportFile) ([]byte, error) {
 		if strings.TrimSpace(f.StorageRegion) != "" {
 			s3cfg.Region = strings.TrimSpace(f.StorageRegion)
 		}
+		// MinIO clients are bound to endpoint/region at construction.
 		s3c, err := storage.NewS3Client(s3cfg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to storage (%s)", strings.TrimSpace(f.Path))
@@ -238,12 +647,32 @@ func decryptRemoteExportFile(f remoteExportFile) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to download from storage (%s)", strings.TrimSpace(f.Path))
 		}
+		verbosef("Downloaded %d bytes from storage", len(raw))
 		blobB64 = base64.RawURLEncoding.EncodeToString(raw)
 	}
 
-	plain, err := auth.DecryptEnvBlob(cipherName, blobB64)
+	if err := checkRemoteCipher(root, f); err != nil {
+		return nil, err
+	}
+	plain, err := auth.DecryptEnvBlob(cipherName, blobB64, remoteFileBinding(root, f))
 	if err != nil {
+		if errors.Is(err, auth.ErrBlobBinding) {
+			return nil, fmt.Errorf("refusing %s: %w", strings.TrimSpace(f.Path), err)
+		}
 		return nil, fmt.Errorf("failed to decrypt file (%s)", strings.TrimSpace(f.Path))
 	}
+	if err := verifyRemotePlaintext(f, plain); err != nil {
+		return nil, err
+	}
 	return plain, nil
 }
+
+// remoteFileBinding is what the server claims a file is; sentra-v2 blobs only
+// decrypt if it matches what they were encrypted for.
+func remoteFileBinding(root string, f remoteExportFile) auth.BlobBinding {
+	return auth.BlobBinding{
+		Root:   strings.TrimSpace(root),
+		Path:   strings.TrimSpace(f.Path),
+		SHA256: strings.TrimSpace(f.SHA256),
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const syncUsage = "usage: sentra sync [<project>...] [--only <glob>]... [--exclude <glob>]... [--at <commit>] [--dry-run [--json]] [--yes]"
+
+type syncOptions struct {
+	// Projects limits sync to these projects, by folder or ID (all when empty).
+	Projects []string
+	// Only and Exclude are globs matched against each file's path within its
+	// project and against its base name.
+	Only    []string
+	Exclude []string
+	// At syncs a single project at this commit instead of its head.
+	At string
+	// DryRun plans the sync without writing anything.
+	DryRun bool
+	// JSON prints the dry-run plan as JSON and nothing else on stdout.
+	JSON bool
+	// Yes removes files deleted on the remote without asking.
+	Yes bool
+}
+
+func parseSyncArgs(args []string) (syncOptions, error) {
+	var opts syncOptions
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		name, value, hasValue := strings.Cut(a, "=")
+		switch name {
+		case "--only", "--exclude", "--at":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return syncOptions{}, errors.New(syncUsage)
+				}
+				i++
+				value = args[i]
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return syncOptions{}, errors.New(syncUsage)
+			}
+			switch name {
+			case "--only":
+				opts.Only = append(opts.Only, value)
+			case "--exclude":
+				opts.Exclude = append(opts.Exclude, value)
+			default:
+				opts.At = value
+			}
+			continue
+		}
+
+		switch a {
+		case "--dry-run", "-n":
+			opts.DryRun = true
+		case "--json":
+			opts.JSON = true
+		case "--yes", "-y":
+			opts.Yes = true
+		default:
+			if strings.HasPrefix(a, "-") {
+				return syncOptions{}, errors.New(syncUsage)
+			}
+			// A folder, or an ID such as github.com/org/api.
+			project := strings.Trim(strings.TrimPrefix(a, "./"), "/")
+			if project == "" {
+				return syncOptions{}, errors.New(syncUsage)
+			}
+			opts.Projects = append(opts.Projects, project)
+		}
+	}
+
+	if opts.JSON && !opts.DryRun {
+		return syncOptions{}, errors.New(syncUsage)
+	}
+	if opts.At != "" && len(opts.Projects) != 1 {
+		return syncOptions{}, errors.New("sentra sync --at needs exactly one project (e.g. sentra sync api --at <commit>)")
+	}
+	for _, g := range append(append([]string{}, opts.Only...), opts.Exclude...) {
+		if _, err := path.Match(g, ""); err != nil {
+			return syncOptions{}, fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+	}
+	return opts, nil
+}
+
+// spinner returns nil in JSON mode so progress never mixes with the plan on
+// stdout (spinner methods are nil-safe).
+func (o syncOptions) spinner(message string) *spinner {
+	if o.JSON {
+		return nil
+	}
+	return startSpinner(message)
+}
+
+// wantsProject reports whether project (a folder or ID) was selected on the
+// command line.
+func (o syncOptions) wantsProject(project string) bool {
+	if len(o.Projects) == 0 {
+		return true
+	}
+	for _, p := range o.Projects {
+		if p == project {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsFile applies --only and --exclude to a remote file path ("<id>/.env").
+func (o syncOptions) wantsFile(id string, filePath string) bool {
+	rel := strings.TrimPrefix(filePath, id+"/")
+	if len(o.Only) > 0 && !matchAnyGlob(o.Only, rel) {
+		return false
+	}
+	return !matchAnyGlob(o.Exclude, rel)
+}
+
+func matchAnyGlob(globs []string, rel string) bool {
+	base := path.Base(rel)
+	for _, g := range globs {
+		if ok, _ := path.Match(g, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, base); ok {
+			return true
+		}
+	}
+	return false
+}
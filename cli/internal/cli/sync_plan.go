@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// syncPlan is the output of `sentra sync --dry-run`.
+type syncPlan struct {
+	DryRun   bool              `json:"dry_run"`
+	Projects []syncPlanProject `json:"projects"`
+}
+
+type syncPlanProject struct {
+	// Root is the local folder, or the ID when the project is missing here.
+	Root string `json:"root"`
+	ID   string `json:"id"`
+	// Status is "sync", "missing" (no local directory) or "empty" (no
+	// remote files, or none left after --only/--exclude).
+	Status string         `json:"status"`
+	Files  []syncPlanFile `json:"files,omitempty"`
+}
+
+// syncPlanFile lists affected key names only; values are never included.
+type syncPlanFile struct {
+	Path      string   `json:"path"`
+	Action    string   `json:"action"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	Changed   []string `json:"changed,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+func planProject(root, id string, actions []syncAction) syncPlanProject {
+	out := syncPlanProject{Root: root, ID: id, Status: "sync", Files: make([]syncPlanFile, 0, len(actions))}
+	for _, a := range actions {
+		f := syncPlanFile{Path: a.Rel, Action: strings.ReplaceAll(a.Kind.String(), " ", "_")}
+		if a.writes() || a.Kind == syncDelete {
+			for _, ch := range diffEnvKeys(a.Local, a.Result) {
+				switch ch.Op {
+				case '+':
+					f.Added = append(f.Added, ch.Key)
+				case '-':
+					f.Removed = append(f.Removed, ch.Key)
+				default:
+					f.Changed = append(f.Changed, ch.Key)
+				}
+			}
+		}
+		for _, cf := range a.Conflicts {
+			f.Conflicts = append(f.Conflicts, cf.Key)
+		}
+		out.Files = append(out.Files, f)
+	}
+	return out
+}
+
+func printSyncPlan(plan syncPlan, asJSON bool) error {
+	if asJSON {
+		b, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(b))
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, p := range plan.Projects {
+		switch p.Status {
+		case "missing":
+			fmt.Println(c(ansiBold, p.Root) + " " + c(ansiDim, "(skipped: not found under scan root)"))
+			counts["missing"]++
+			continue
+		case "empty":
+			fmt.Println(c(ansiBold, p.Root) + " " + c(ansiDim, "(no matching remote files)"))
+			continue
+		}
+		fmt.Println(c(ansiBold, p.Root))
+		for _, f := range p.Files {
+			counts[f.Action]++
+			fmt.Println("  " + formatPlanFile(f))
+		}
+	}
+
+	fmt.Println()
+	infof("dry run: %d create, %d update, %d merge, %d delete, %d unchanged, %d keep local, %d conflict, %d project(s) missing. Nothing was written.",
+		counts["create"], counts["update"], counts["merge"], counts["delete"], counts["unchanged"], counts["keep_local"], counts["conflict"], counts["missing"])
+	return nil
+}
+
+func formatPlanFile(f syncPlanFile) string {
+	var keys []string
+	for _, k := range f.Added {
+		keys = append(keys, "+"+k)
+	}
+	for _, k := range f.Changed {
+		keys = append(keys, "~"+k)
+	}
+	for _, k := range f.Removed {
+		keys = append(keys, "-"+k)
+	}
+	summary := ""
+	if len(keys) > 0 {
+		summary = " " + c(ansiDim, strings.Join(keys, " "))
+	}
+
+	switch f.Action {
+	case "create":
+		return c(ansiGreen, "+ "+f.Path+" (create)") + summary
+	case "update", "merge":
+		return c(ansiYellow, "~ "+f.Path+" ("+f.Action+")") + summary
+	case "delete":
+		return c(ansiRed, "- "+f.Path+" (delete: removed on remote)") + summary
+	case "conflict":
+		return c(ansiRed, "! "+f.Path+" (conflict: "+strings.Join(f.Conflicts, ", ")+")") + summary
+	case "keep_local":
+		return c(ansiDim, "= "+f.Path+" (local changes kept)")
+	default:
+		return c(ansiDim, "= "+f.Path+" (unchanged)")
+	}
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+	"github.com/mgeovany/sentra/cli/internal/commit"
+	"github.com/mgeovany/sentra/cli/internal/objects"
+	"github.com/mgeovany/sentra/cli/internal/scanner"
+	"github.com/mgeovany/sentra/cli/internal/storage"
+	"github.com/zalando/go-keyring"
+)
+
+// newTestHome points HOME (~/.sentra) at a temporary directory and keeps
+// keys out of the real OS keyring.
+func newTestHome(t *testing.T) string {
+	t.Helper()
+	keyring.MockInit()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	loadedProjectIDs = map[string]*projectIDs{}
+	scannedProjects = map[string][]scanner.Project{}
+	return home
+}
+
+// newTestLayout is newTestHome with an org/repo checkout under ~/dev, a
+// worktree folded into it, and a project with a submodule. It returns the
+// scan root.
+func newTestLayout(t *testing.T) string {
+	t.Helper()
+	scanRoot := filepath.Join(newTestHome(t), "dev")
+	writeTestFile(t, filepath.Join(scanRoot, ".sentra.yml"), "submodules: true\nworktrees: main\n")
+	api := filepath.Join(scanRoot, "acme", "api")
+	if err := os.MkdirAll(filepath.Join(api, ".git", "worktrees", "api-feature"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(api, ".env"), "A=main\n")
+	admin := filepath.Join(api, ".git", "worktrees", "api-feature")
+	writeTestFile(t, filepath.Join(admin, "commondir"), "../..\n")
+	writeTestFile(t, filepath.Join(scanRoot, "acme", "api-feature", ".git"), "gitdir: "+admin+"\n")
+	writeTestFile(t, filepath.Join(scanRoot, "acme", "api-feature", ".env.local"), "B=feature\n")
+	if err := os.MkdirAll(filepath.Join(scanRoot, "app", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(scanRoot, "app", ".env"), "C=1\n")
+	writeTestFile(t, filepath.Join(scanRoot, "app", "libs", "auth", ".git"), "gitdir: ../../.git/modules/libs/auth\n")
+	writeTestFile(t, filepath.Join(scanRoot, "app", "libs", "auth", ".env"), "D=1\n")
+	return scanRoot
+}
+
+// layoutCommit commits the working copy of paths, as add and commit do.
+func layoutCommit(t *testing.T, scanRoot string, paths ...string) commit.Commit {
+	t.Helper()
+	ids, err := loadProjectIDs(scanRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	snapshots := map[string]string{}
+	for _, p := range paths {
+		b, err := os.ReadFile(filepath.Join(scanRoot, filepath.FromSlash(p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snapshots[p], err = objects.Put(b); err != nil {
+			t.Fatal(err)
+		}
+		files[p] = scanner.HashEnv(ids.relInProject(p), b)
+	}
+	return commit.New("layout", files, snapshots)
+}
+
+// selfRecipients encrypts to this machine alone, as machine-a.
+func selfRecipients(t *testing.T) []auth.Recipient {
+	t.Helper()
+	self, err := auth.GetOrCreateDeviceEncryptionPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []auth.Recipient{{MachineID: "machine-a", PublicKey: self}}
+}
+
+func writeTestFile(t *testing.T, p string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exportAfterPush plays the remote: the export a project returns once reqs
+// are pushed on top of files.
+func exportAfterPush(files map[string]remoteExportFile, reqs []pushRequestV1) []remoteExportFile {
+	for _, req := range reqs {
+		for _, f := range req.Files {
+			files[f.Path] = remoteExportFile{CommitID: req.Commit.ClientID, Path: f.Path, SHA256: f.SHA256, Size: f.Size, Cipher: f.Cipher, BlobB64: f.Blob}
+		}
+		for _, d := range req.Deleted {
+			files[d.Path] = remoteExportFile{CommitID: req.Commit.ClientID, Path: d.Path, Deleted: true}
+		}
+	}
+	out := make([]remoteExportFile, 0, len(files))
+	for _, f := range files {
+		out = append(out, f)
+	}
+	return out
+}
+
+func TestDeletePushSyncRoundTrip(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	if err := os.MkdirAll(filepath.Join(scanRoot, "api", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Machine A deletes .env.local and .env.test and pushes the tombstones.
+	deleting := commit.New("drop local envs", nil, nil)
+	deleting.Deleted = []string{"api/.env.local", "api/.env.test"}
+	reqs, err := buildPushRequestV1(context.Background(), scanRoot, "machine-a", "a", deleting, nil, storage.S3Config{}, nil, false, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || len(reqs[0].Files) != 0 || len(reqs[0].Deleted) != 2 {
+		t.Fatalf("push requests = %+v", reqs)
+	}
+	if reqs[0].Project.Root != "api" || reqs[0].Deleted[0].Path != "api/.env.local" || reqs[0].Deleted[1].Path != "api/.env.test" {
+		t.Fatalf("tombstones = %+v", reqs[0].Deleted)
+	}
+
+	remote := exportAfterPush(map[string]remoteExportFile{}, reqs)
+
+	// Machine B still has both files from its last sync, and has since
+	// edited .env.test.
+	synced := map[string]commit.SyncedFile{}
+	at := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, rel := range []string{"api/.env.local", "api/.env.test"} {
+		id, err := objects.Put([]byte("KEY=synced\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		synced[rel] = commit.SyncedFile{Object: id, At: at}
+	}
+	writeTestFile(t, filepath.Join(scanRoot, "api", ".env.local"), "KEY=synced\n")
+	writeTestFile(t, filepath.Join(scanRoot, "api", ".env.test"), "KEY=edited\n")
+	heads := commit.Heads{Projects: map[string]string{}, Synced: synced}
+
+	actions, err := planProjectSync(scanRoot, "api", "api", remote, heads, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]syncActionKind{}
+	for _, a := range actions {
+		if !a.Tombstone {
+			t.Fatalf("%s: not planned as a tombstone", a.Rel)
+		}
+		kinds[a.Rel] = a.Kind
+	}
+	if kinds["api/.env.local"] != syncDelete {
+		t.Fatalf("unchanged deleted file: %v, want delete", kinds["api/.env.local"])
+	}
+	if kinds["api/.env.test"] != syncKeepLocal {
+		t.Fatalf("locally edited deleted file: %v, want keep local", kinds["api/.env.test"])
+	}
+
+	// A tombstone for a file machine B never had is nothing to do.
+	if err := os.Remove(filepath.Join(scanRoot, "api", ".env.local")); err != nil {
+		t.Fatal(err)
+	}
+	actions, err = planProjectSync(scanRoot, "api", "api", remote, heads, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Rel != "api/.env.test" {
+		t.Fatalf("actions = %+v", actions)
+	}
+}
+
+func TestSyncKeepsUnpushedCommit(t *testing.T) {
+	home := newTestHome(t)
+	scanRoot := filepath.Join(home, "dev")
+	if err := os.MkdirAll(filepath.Join(scanRoot, "api", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	recipients := selfRecipients(t)
+	envPath := filepath.Join(scanRoot, "api", ".env")
+
+	// The remote moves on to A=3 ...
+	writeTestFile(t, envPath, "A=3\n")
+	reqs, err := buildPushRequestV1(context.Background(), scanRoot, "machine-b", "b", layoutCommit(t, scanRoot, "api/.env"), recipients, storage.S3Config{}, nil, false, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := exportAfterPush(map[string]remoteExportFile{}, reqs)
+
+	// ... while this machine, last synced at A=1, committed A=2 and has not
+	// pushed it.
+	synced, err := objects.Put([]byte("A=1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	heads := commit.Heads{Projects: map[string]string{}, Synced: map[string]commit.SyncedFile{
+		"api/.env": {Object: synced, At: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)},
+	}}
+	writeTestFile(t, envPath, "A=2\n")
+	pending := []commit.Commit{layoutCommit(t, scanRoot, "api/.env")}
+
+	actions, err := planProjectSync(scanRoot, "api", "api", remote, heads, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Kind != syncConflict {
+		t.Fatalf("actions = %+v, want a conflict, not the remote overwriting the commit", actions)
+	}
+
+	// A remote deletion keeps the committed file, with or without a synced
+	// base.
+	deleting := commit.New("drop", nil, nil)
+	deleting.Deleted = []string{"api/.env"}
+	reqs, err = buildPushRequestV1(context.Background(), scanRoot, "machine-b", "b", deleting, recipients, storage.S3Config{}, nil, false, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote = exportAfterPush(map[string]remoteExportFile{}, reqs)
+	for _, h := range []commit.Heads{heads, {}} {
+		actions, err := planProjectSync(scanRoot, "api", "api", remote, h, pending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actions) != 1 || actions[0].Kind != syncKeepLocal {
+			t.Fatalf("actions = %+v, want the committed file kept", actions)
+		}
+	}
+}
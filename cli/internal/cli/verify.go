@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+)
+
+var errIntegrity = errors.New("integrity check failed")
+
+// verifyRemotePlaintext checks decrypted bytes against the sha256 and size
+// recorded at push time.
+func verifyRemotePlaintext(f remoteExportFile, plain []byte) error {
+	path := strings.TrimSpace(f.Path)
+	want := strings.ToLower(strings.TrimSpace(f.SHA256))
+	if want == "" {
+		return fmt.Errorf("%w for %s: server sent no sha256", errIntegrity, path)
+	}
+	if got := auth.SHA256Hex(plain); got != want {
+		return fmt.Errorf("%w for %s: sha256 %s, expected %s", errIntegrity, path, got, want)
+	}
+	if f.Size != len(plain) {
+		return fmt.Errorf("%w for %s: size %d, expected %d", errIntegrity, path, len(plain), f.Size)
+	}
+	return nil
+}
+
+// sentra verify <project> [--at <commit>]
+// Downloads, decrypts and checks every file of a project. Writes nothing.
+func runVerify(args []string) error {
+	project, at, err := parseExportArgs(args)
+	if err != nil {
+		return errors.New("usage: sentra verify <project> [--at <commit>]")
+	}
+	root, err := remoteProjectID(project)
+	if err != nil {
+		return err
+	}
+
+	sess, err := ensureRemoteSession()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(sess.AccessToken) == "" {
+		return errors.New("not logged in (run: sentra login)")
+	}
+	serverURL, err := serverURLFromEnv()
+	if err != nil {
+		return err
+	}
+
+	sp := startSpinner(fmt.Sprintf("Fetching %s...", root))
+	files, err := fetchRemoteExportAt(serverURL, sess.AccessToken, root, at)
+	if err != nil {
+		sp.StopInfo("")
+		return err
+	}
+	sp.StopInfo("")
+	if len(files) == 0 {
+		fmt.Println("✔ 0 files")
+		return nil
+	}
+
+	failed := 0
+	checked := 0
+	for _, f := range files {
+		if f.Deleted {
+			fmt.Printf("%s %s %s\n", c(ansiDim, "-"), strings.TrimSpace(f.Path), c(ansiDim, "(deleted)"))
+			continue
+		}
+		checked++
+		if _, err := decryptRemoteExportFile(root, f); err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", c(ansiRed, "✖"), strings.TrimSpace(f.Path), err)
+			continue
+		}
+		fmt.Printf("%s %s %s\n", c(ansiGreen, "✔"), strings.TrimSpace(f.Path), c(ansiDim, fmt.Sprintf("(%d bytes, %s)", f.Size, shortSHA(f.SHA256))))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("verify: %d of %d file(s) failed", failed, checked)
+	}
+	successf("✔ %d file(s) verified", checked)
+	return nil
+}
+
+func shortSHA(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 12 {
+		return s[:12]
+	}
+	return s
+}
@@ -34,6 +34,8 @@ func runWipe(args []string) error {
 	_ = keyring.Delete("sentra", "session")
 	_ = keyring.Delete("sentra", "session-key")
 	_ = keyring.Delete("sentra", "device-ed25519")
+	_ = keyring.Delete("sentra", "device-x25519")
+	_ = keyring.Delete("sentra", "linked-keys")
 
 	// Remove all local state.
 	if err := os.RemoveAll(sentraDir); err != nil {
@@ -61,7 +63,7 @@ func confirmWipe(sentraDir string) error {
 
 	fmt.Println("This will delete ALL local Sentra data:")
 	fmt.Printf("- %s (commits, index, config, cache)\n", sentraDir)
-	fmt.Println("- keychain items: sentra/session, sentra/session-key, sentra/device-ed25519")
+	fmt.Println("- keychain items: sentra/session, sentra/session-key, sentra/device-ed25519, sentra/device-x25519, sentra/linked-keys")
 	fmt.Println()
 	fmt.Print("Type WIPE to continue: ")
 
@@ -18,8 +18,19 @@ type Commit struct {
 	CreatedAt string            `json:"createdAt"`
 	Message   string            `json:"message"`
 	Files     map[string]string `json:"files"`
-	PushedAt  string            `json:"pushedAt,omitempty"`
-	Version   int               `json:"version"`
+	// Objects maps each file path to the id of its content snapshot in the
+	// local object store (see package objects). Commits created before the
+	// object store existed have no entries and are read from the working copy.
+	Objects map[string]string `json:"objects,omitempty"`
+	// Deleted lists file paths this commit removes. They are pushed as
+	// tombstones so other machines stop restoring them.
+	Deleted []string `json:"deleted,omitempty"`
+	// Parents maps each project root touched by this commit to the commit
+	// that preceded it for that project (local or remote), forming a chain.
+	// Empty for the first commit of a project.
+	Parents  map[string]string `json:"parents,omitempty"`
+	PushedAt string            `json:"pushedAt,omitempty"`
+	Version  int               `json:"version"`
 }
 
 func Dir() (string, error) {
@@ -30,7 +41,7 @@ func Dir() (string, error) {
 	return filepath.Join(homeDir, ".sentra", "commits"), nil
 }
 
-func New(message string, files map[string]string) Commit {
+func New(message string, files map[string]string, objects map[string]string) Commit {
 	now := time.Now().UTC()
 	id := uuid.NewString()
 
@@ -38,14 +49,102 @@ func New(message string, files map[string]string) Commit {
 	for k, v := range files {
 		copyFiles[k] = v
 	}
+	copyObjects := make(map[string]string, len(objects))
+	for k, v := range objects {
+		copyObjects[k] = v
+	}
 
 	return Commit{
 		ID:        id,
-		CreatedAt: now.Format(time.RFC3339),
+		CreatedAt: now.Format(time.RFC3339Nano),
 		Message:   strings.TrimSpace(message),
 		Files:     copyFiles,
-		Version:   1,
+		Objects:   copyObjects,
+		Version:   2,
+	}
+}
+
+// Paths returns every path the commit touches, written or deleted, sorted.
+func (c Commit) Paths() []string {
+	out := make([]string, 0, len(c.Files)+len(c.Deleted))
+	for p := range c.Files {
+		out = append(out, p)
+	}
+	for _, p := range c.Deleted {
+		if _, ok := c.Files[p]; !ok {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Deletes reports whether the commit removes path.
+func (c Commit) Deletes(path string) bool {
+	for _, p := range c.Deleted {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Fold applies later on top of c, as if both had been one commit: files later
+// writes replace c's, and files it deletes are no longer written. Parents
+// already recorded on c are kept.
+func (c *Commit) Fold(later Commit) {
+	if c.Files == nil {
+		c.Files = map[string]string{}
+	}
+	if c.Objects == nil {
+		c.Objects = map[string]string{}
+	}
+	deleted := map[string]bool{}
+	for _, p := range c.Deleted {
+		deleted[p] = true
+	}
+	for p, hash := range later.Files {
+		c.Files[p] = hash
+		delete(c.Objects, p)
+		if id, ok := later.ObjectID(p); ok {
+			c.Objects[p] = id
+		}
+		delete(deleted, p)
+	}
+	for _, p := range later.Deleted {
+		delete(c.Files, p)
+		delete(c.Objects, p)
+		deleted[p] = true
+	}
+	c.Deleted = c.Deleted[:0]
+	for p := range deleted {
+		c.Deleted = append(c.Deleted, p)
+	}
+	sort.Strings(c.Deleted)
+	if len(c.Deleted) == 0 {
+		c.Deleted = nil
 	}
+	for root, parent := range later.Parents {
+		if c.ParentFor(root) != "" {
+			continue
+		}
+		if c.Parents == nil {
+			c.Parents = map[string]string{}
+		}
+		c.Parents[root] = parent
+	}
+}
+
+// ObjectID returns the object store id recorded for path, if any.
+func (c Commit) ObjectID(path string) (string, bool) {
+	if c.Objects == nil {
+		return "", false
+	}
+	id, ok := c.Objects[path]
+	if !ok || strings.TrimSpace(id) == "" {
+		return "", false
+	}
+	return id, true
 }
 
 func Save(c Commit) (string, error) {
@@ -124,10 +223,43 @@ func List() ([]Commit, error) {
 		commits = append(commits, c)
 	}
 
-	sort.Slice(commits, func(i, j int) bool { return commits[i].ID < commits[j].ID })
+	Sort(commits)
 	return commits, nil
 }
 
+// Sort orders commits oldest first. IDs are random UUIDs, so creation time is
+// the only meaningful order; the ID only breaks ties.
+func Sort(commits []Commit) {
+	sort.SliceStable(commits, func(i, j int) bool {
+		ti, tj := createdAt(commits[i]), createdAt(commits[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return commits[i].ID < commits[j].ID
+	})
+}
+
+// ParentFor returns the parent recorded for root, if any.
+func (c Commit) ParentFor(root string) string {
+	if c.Parents == nil {
+		return ""
+	}
+	return strings.TrimSpace(c.Parents[root])
+}
+
+// Time returns when c was created (zero if the timestamp is malformed).
+func (c Commit) Time() time.Time {
+	return createdAt(c)
+}
+
+func createdAt(c Commit) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(c.CreatedAt))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 func Update(c Commit) error {
 	_, err := Save(c)
 	return err
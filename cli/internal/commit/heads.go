@@ -0,0 +1,126 @@
+package commit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Heads records the last known remote head (commit client id) per remote
+// project ID (the folder name for projects without one).
+// It is updated after a successful push and whenever the server reports a newer head.
+type Heads struct {
+	Version  int               `json:"version"`
+	Projects map[string]string `json:"projects"`
+	// Synced records, per file path, the contents sync last wrote (or found
+	// already in place). It is the merge base for the next sync.
+	Synced map[string]SyncedFile `json:"synced,omitempty"`
+}
+
+// SyncedFile is a snapshot (see package objects) taken when a file was synced.
+type SyncedFile struct {
+	Object string `json:"object"`
+	At     string `json:"at"`
+}
+
+func HeadsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sentra", "heads.json"), nil
+}
+
+func LoadHeads() (Heads, error) {
+	p, err := HeadsPath()
+	if err != nil {
+		return Heads{}, err
+	}
+
+	h := Heads{Version: 1, Projects: map[string]string{}}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return Heads{}, err
+	}
+	if err := json.Unmarshal(b, &h); err != nil {
+		return Heads{}, err
+	}
+	if h.Version == 0 {
+		h.Version = 1
+	}
+	if h.Projects == nil {
+		h.Projects = map[string]string{}
+	}
+	return h, nil
+}
+
+// SetHead records id as the remote head for root.
+func SetHead(root string, id string) error {
+	root = strings.TrimSpace(root)
+	id = strings.TrimSpace(id)
+	if root == "" || id == "" {
+		return nil
+	}
+
+	h, err := LoadHeads()
+	if err != nil {
+		return err
+	}
+	if h.Projects[root] == id {
+		return nil
+	}
+	h.Projects[root] = id
+	return saveHeads(h)
+}
+
+// SetSynced records the object ids of files that now match the remote. An
+// empty id forgets the file (it was deleted).
+func SetSynced(files map[string]string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	h, err := LoadHeads()
+	if err != nil {
+		return err
+	}
+	if h.Synced == nil {
+		h.Synced = map[string]SyncedFile{}
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for p, id := range files {
+		if id == "" {
+			delete(h.Synced, p)
+			continue
+		}
+		h.Synced[p] = SyncedFile{Object: id, At: now}
+	}
+	return saveHeads(h)
+}
+
+func saveHeads(h Heads) error {
+	p, err := HeadsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := p + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package dotenv parses .env files into an ordered list of entries without
+// losing anything: comments, blank lines, `export` prefixes, quoting styles
+// and multiline values are kept, and an unmodified file is written back
+// byte-for-byte.
+package dotenv
+
+import (
+	"bytes"
+	"strings"
+)
+
+type Kind int
+
+const (
+	// Blank is an empty or whitespace-only line.
+	Blank Kind = iota
+	// Comment is a full-line comment.
+	Comment
+	// Assignment is KEY=value (optionally prefixed with export).
+	Assignment
+	// Invalid is a line that could not be parsed; it is kept verbatim.
+	Invalid
+)
+
+// Quote is the quoting style of a value.
+type Quote byte
+
+const (
+	Unquoted Quote = 0
+	Single   Quote = '\''
+	Double   Quote = '"'
+	Backtick Quote = '`'
+)
+
+// Entry is one logical line of a .env file. A multiline quoted value is a
+// single entry spanning several physical lines.
+type Entry struct {
+	Kind Kind
+	// Line is the 1-based line number the entry starts on.
+	Line int
+
+	Export bool
+	Key    string
+	// Value is the decoded value (quotes removed, escapes in double quotes applied).
+	Value string
+	Quote Quote
+	// InlineComment is the text after '#' following the value, if any.
+	InlineComment string
+	HasComment    bool
+
+	// Err describes why an Invalid entry could not be parsed.
+	Err string
+
+	// raw is the exact source text including the line ending. It is cleared
+	// when the entry is modified so that it gets re-rendered.
+	raw string
+	// eol is the entry's line ending ("\n", "\r\n" or "" at EOF).
+	eol string
+}
+
+// File is a parsed .env file.
+type File struct {
+	Entries []Entry
+	// eol is the dominant line ending, used for new entries.
+	eol string
+}
+
+// Problem is a parse issue reported for an Invalid entry.
+type Problem struct {
+	Line int
+	Msg  string
+}
+
+// Parse never fails: lines it doesn't understand become Invalid entries and
+// are written back unchanged. See Problems.
+func Parse(b []byte) *File {
+	src := string(b)
+	f := &File{eol: detectEOL(src)}
+
+	lineNo := 1
+	for len(src) > 0 {
+		e, n := parseEntry(src, lineNo)
+		f.Entries = append(f.Entries, e)
+		lineNo += strings.Count(src[:n], "\n")
+		src = src[n:]
+	}
+	return f
+}
+
+// Bytes renders the file. Unmodified entries are emitted exactly as parsed.
+func (f *File) Bytes() []byte {
+	var b bytes.Buffer
+	for i, e := range f.Entries {
+		if e.raw != "" {
+			b.WriteString(e.raw)
+			continue
+		}
+		b.WriteString(e.render())
+		eol := e.eol
+		if eol == "" && i < len(f.Entries)-1 {
+			eol = f.eol
+		}
+		b.WriteString(eol)
+	}
+	return b.Bytes()
+}
+
+// Problems lists the Invalid entries.
+func (f *File) Problems() []Problem {
+	var out []Problem
+	for _, e := range f.Entries {
+		if e.Kind == Invalid {
+			out = append(out, Problem{Line: e.Line, Msg: e.Err})
+		}
+	}
+	return out
+}
+
+// Keys returns assignment keys in file order, without duplicates.
+func (f *File) Keys() []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, e := range f.Entries {
+		if e.Kind != Assignment {
+			continue
+		}
+		if _, ok := seen[e.Key]; ok {
+			continue
+		}
+		seen[e.Key] = struct{}{}
+		out = append(out, e.Key)
+	}
+	return out
+}
+
+// Get returns the value of key. If a key is assigned more than once, the
+// last assignment wins, as when the file is loaded.
+func (f *File) Get(key string) (string, bool) {
+	if i := f.index(key); i >= 0 {
+		return f.Entries[i].Value, true
+	}
+	return "", false
+}
+
+// Map returns all assignments (last one wins).
+func (f *File) Map() map[string]string {
+	out := map[string]string{}
+	for _, e := range f.Entries {
+		if e.Kind == Assignment {
+			out[e.Key] = e.Value
+		}
+	}
+	return out
+}
+
+// Set changes the value of key, keeping its export prefix, inline comment
+// and (if it can represent the value) its quoting. A new key is appended.
+func (f *File) Set(key, value string) {
+	if i := f.index(key); i >= 0 {
+		e := &f.Entries[i]
+		if e.Value == value {
+			return
+		}
+		e.Value = value
+		if !canQuote(e.Quote, value) {
+			e.Quote = chooseQuote(value)
+		}
+		e.raw = ""
+		return
+	}
+
+	// Make sure the previous last line is terminated before appending.
+	if n := len(f.Entries); n > 0 && f.Entries[n-1].eol == "" {
+		last := &f.Entries[n-1]
+		last.eol = f.eol
+		if last.raw != "" {
+			last.raw += f.eol
+		}
+	}
+	f.Entries = append(f.Entries, Entry{
+		Kind:  Assignment,
+		Key:   key,
+		Value: value,
+		Quote: chooseQuote(value),
+		eol:   f.eol,
+	})
+}
+
+// Delete removes every assignment of key. It reports whether any existed.
+func (f *File) Delete(key string) bool {
+	out := f.Entries[:0]
+	found := false
+	for _, e := range f.Entries {
+		if e.Kind == Assignment && e.Key == key {
+			found = true
+			continue
+		}
+		out = append(out, e)
+	}
+	f.Entries = out
+	return found
+}
+
+func (f *File) index(key string) int {
+	for i := len(f.Entries) - 1; i >= 0; i-- {
+		if f.Entries[i].Kind == Assignment && f.Entries[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func detectEOL(s string) string {
+	if i := strings.IndexByte(s, '\n'); i > 0 && s[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
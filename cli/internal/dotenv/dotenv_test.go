@@ -0,0 +1,175 @@
+package dotenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		want     map[string]string
+		problems int
+	}{
+		{
+			name: "empty",
+			src:  "",
+			want: map[string]string{},
+		},
+		{
+			name: "plain",
+			src:  "A=1\nB=two words\nEMPTY=\n",
+			want: map[string]string{"A": "1", "B": "two words", "EMPTY": ""},
+		},
+		{
+			name: "no final newline",
+			src:  "A=1\nB=2",
+			want: map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name: "spacing around key and value",
+			src:  "  A = 1  \n\tB\t=\t2\n",
+			want: map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name: "quotes",
+			src:  "D=\"a \\\"b\\\" \\n c\"\nS='no $EXPANSION \\n'\nBT=`x \"y\" 'z'`\nH=\"#not a comment\"\n",
+			want: map[string]string{"D": "a \"b\" \n c", "S": `no $EXPANSION \n`, "BT": `x "y" 'z'`, "H": "#not a comment"},
+		},
+		{
+			name: "unknown escapes are kept",
+			src:  `P="C:\dir\n\q"` + "\n",
+			want: map[string]string{"P": "C:\\dir\n\\q"},
+		},
+		{
+			name: "export",
+			src:  "export A=1\nexport\tB=\"2\"\nexporter=3\n",
+			want: map[string]string{"A": "1", "B": "2", "exporter": "3"},
+		},
+		{
+			name: "comments",
+			src:  "# header\n\n  # indented\nA=1 # trailing\nB=a#b\nC=\"q\"   # after quote\nD= # only a comment\n",
+			want: map[string]string{"A": "1", "B": "a#b", "C": "q", "D": ""},
+		},
+		{
+			name: "multiline",
+			src:  "KEY=\"-----BEGIN KEY-----\nabc\ndef\n-----END KEY-----\"\nS='line 1\nline 2' # note\nAFTER=1\n",
+			want: map[string]string{"KEY": "-----BEGIN KEY-----\nabc\ndef\n-----END KEY-----", "S": "line 1\nline 2", "AFTER": "1"},
+		},
+		{
+			name: "crlf",
+			src:  "# c\r\nA=1\r\n\r\nexport B=\"x\r\ny\" # c\r\nC='z'\r\n",
+			want: map[string]string{"A": "1", "B": "x\r\ny", "C": "z"},
+		},
+		{
+			name: "mixed line endings",
+			src:  "A=1\r\nB=2\nC=3",
+			want: map[string]string{"A": "1", "B": "2", "C": "3"},
+		},
+		{
+			name:     "invalid lines are kept",
+			src:      "A=1\nnot an assignment\nB=\"unterminated\nC='x' junk\n1BAD=2\nD=4\n",
+			want:     map[string]string{"A": "1", "D": "4"},
+			problems: 4,
+		},
+		{
+			name: "duplicates",
+			src:  "A=1\nA=2\n",
+			want: map[string]string{"A": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.src))
+			if got := string(f.Bytes()); got != tt.src {
+				t.Fatalf("Bytes() = %q, want %q", got, tt.src)
+			}
+			if got := f.Map(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Map() = %q, want %q", got, tt.want)
+			}
+			if got := len(f.Problems()); got != tt.problems {
+				t.Fatalf("%d problem(s), want %d: %+v", got, tt.problems, f.Problems())
+			}
+		})
+	}
+}
+
+func TestSetKeepsTheRest(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "same value is a no-op",
+			src:   "A = 1 # note\n",
+			key:   "A",
+			value: "1",
+			want:  "A = 1 # note\n",
+		},
+		{
+			name:  "keeps export, quote and comment",
+			src:   "# top\nexport A='old' # note\nB=2\n",
+			key:   "A",
+			value: "new",
+			want:  "# top\nexport A='new' # note\nB=2\n",
+		},
+		{
+			name:  "requotes a value the old style cannot hold",
+			src:   "A=plain\n",
+			key:   "A",
+			value: "two\nlines",
+			want:  "A=\"two\\nlines\"\n",
+		},
+		{
+			name:  "appends with the file's line ending",
+			src:   "A=1\r\nB=2",
+			key:   "C",
+			value: "has space",
+			want:  "A=1\r\nB=2\r\nC=has space\r\n",
+		},
+		{
+			name:  "replaces a multiline value",
+			src:   "K=\"a\nb\"\nZ=1\n",
+			key:   "K",
+			value: "c",
+			want:  "K=\"c\"\nZ=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.src))
+			f.Set(tt.key, tt.value)
+			out := f.Bytes()
+			if string(out) != tt.want {
+				t.Fatalf("Bytes() = %q, want %q", out, tt.want)
+			}
+			again := Parse(out)
+			if got, _ := again.Get(tt.key); got != tt.value {
+				t.Fatalf("re-parsed %s = %q, want %q", tt.key, got, tt.value)
+			}
+			if string(again.Bytes()) != string(out) {
+				t.Fatalf("second round trip changed the file: %q", again.Bytes())
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	f := Parse([]byte("A=1\n# keep\nB=2\nA=3\n"))
+	if !f.Delete("A") {
+		t.Fatal("Delete(A) = false")
+	}
+	if f.Delete("missing") {
+		t.Fatal("Delete(missing) = true")
+	}
+	if got, want := string(f.Bytes()), "# keep\nB=2\n"; got != want {
+		t.Fatalf("Bytes() = %q, want %q", got, want)
+	}
+	if got, want := f.Keys(), []string{"B"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Keys() = %q, want %q", got, want)
+	}
+}
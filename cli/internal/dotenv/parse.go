@@ -0,0 +1,201 @@
+package dotenv
+
+import (
+	"strings"
+)
+
+// parseEntry parses one entry at the start of src and returns it along with
+// the number of bytes consumed (always > 0).
+func parseEntry(src string, lineNo int) (Entry, int) {
+	line, eol, n := nextLine(src)
+	e := Entry{Line: lineNo, raw: src[:n], eol: eol}
+
+	body := strings.TrimLeft(line, " \t")
+	switch {
+	case strings.TrimSpace(body) == "":
+		e.Kind = Blank
+		return e, n
+	case body[0] == '#':
+		e.Kind = Comment
+		return e, n
+	}
+
+	if rest, ok := cutExport(body); ok {
+		e.Export = true
+		body = rest
+	}
+
+	key, rest, ok := cutKey(body)
+	if !ok {
+		return invalid(e, "expected KEY=value"), n
+	}
+	e.Key = key
+	rest = strings.TrimLeft(rest, " \t")
+
+	if rest == "" || (rest[0] != '"' && rest[0] != '\'' && rest[0] != '`') {
+		e.Kind = Assignment
+		e.Value, e.InlineComment, e.HasComment = splitUnquoted(rest)
+		return e, n
+	}
+
+	// Quoted values may continue on the following lines; search from the
+	// value start in the whole remaining input.
+	q := Quote(rest[0])
+	valueStart := n - len(eol) - len(rest) + 1
+	end, ok := findClosingQuote(src[valueStart:], q)
+	if !ok {
+		return invalid(e, "unterminated "+quoteName(q)+" quote"), n
+	}
+	value := src[valueStart : valueStart+end]
+
+	// After the closing quote: optional whitespace and an optional comment,
+	// up to the end of that physical line.
+	afterStart := valueStart + end + 1
+	tail, tailEOL, tailN := nextLine(src[afterStart:])
+	trimmed := strings.TrimLeft(tail, " \t")
+	if trimmed != "" && trimmed[0] != '#' {
+		return invalid(e, "unexpected characters after closing quote"), n
+	}
+
+	e.Kind = Assignment
+	e.Quote = q
+	if q == Double {
+		e.Value = unescapeDouble(value)
+	} else {
+		e.Value = value
+	}
+	if trimmed != "" {
+		e.HasComment = true
+		e.InlineComment = trimmed[1:]
+	}
+	consumed := afterStart + tailN
+	e.raw = src[:consumed]
+	e.eol = tailEOL
+	return e, consumed
+}
+
+func invalid(e Entry, msg string) Entry {
+	e.Kind = Invalid
+	e.Err = msg
+	e.Export = false
+	e.Key = ""
+	return e
+}
+
+// nextLine returns the first physical line without its ending, the ending,
+// and the number of bytes including the ending.
+func nextLine(src string) (line string, eol string, n int) {
+	i := strings.IndexByte(src, '\n')
+	if i < 0 {
+		return src, "", len(src)
+	}
+	if i > 0 && src[i-1] == '\r' {
+		return src[:i-1], "\r\n", i + 1
+	}
+	return src[:i], "\n", i + 1
+}
+
+func cutExport(s string) (string, bool) {
+	const kw = "export"
+	if !strings.HasPrefix(s, kw) || len(s) == len(kw) {
+		return s, false
+	}
+	if c := s[len(kw)]; c != ' ' && c != '\t' {
+		return s, false
+	}
+	return strings.TrimLeft(s[len(kw):], " \t"), true
+}
+
+// cutKey reads KEY, optional whitespace and '='.
+func cutKey(s string) (key string, rest string, ok bool) {
+	i := 0
+	for i < len(s) && isKeyByte(s[i], i == 0) {
+		i++
+	}
+	if i == 0 {
+		return "", "", false
+	}
+	key = s[:i]
+	rest = strings.TrimLeft(s[i:], " \t")
+	if rest == "" || rest[0] != '=' {
+		return "", "", false
+	}
+	return key, rest[1:], true
+}
+
+func isKeyByte(c byte, first bool) bool {
+	switch {
+	case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		return true
+	case first:
+		return false
+	case c >= '0' && c <= '9', c == '.', c == '-':
+		return true
+	}
+	return false
+}
+
+// splitUnquoted splits `value # comment`. A '#' only starts a comment at the
+// beginning or after whitespace, so `a#b` stays a value.
+func splitUnquoted(s string) (value string, comment string, hasComment bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimRight(s[:i], " \t"), s[i+1:], true
+		}
+	}
+	return strings.TrimRight(s, " \t"), "", false
+}
+
+// findClosingQuote returns the index of the closing quote in s (which starts
+// just after the opening quote). Only double quotes support escapes.
+func findClosingQuote(s string, q Quote) (int, bool) {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case q == Double && s[i] == '\\':
+			i++
+		case s[i] == byte(q):
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func unescapeDouble(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\', '$', '`':
+			b.WriteByte(s[i])
+		default:
+			// Unknown escapes are kept as written.
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func quoteName(q Quote) string {
+	switch q {
+	case Single:
+		return "single"
+	case Backtick:
+		return "backtick"
+	default:
+		return "double"
+	}
+}
@@ -0,0 +1,63 @@
+package dotenv
+
+import "strings"
+
+// render formats a modified or new entry.
+func (e Entry) render() string {
+	if e.Kind != Assignment {
+		return ""
+	}
+	var b strings.Builder
+	if e.Export {
+		b.WriteString("export ")
+	}
+	b.WriteString(e.Key)
+	b.WriteByte('=')
+	b.WriteString(quoteValue(e.Quote, e.Value))
+	if e.HasComment {
+		b.WriteString(" #")
+		b.WriteString(e.InlineComment)
+	}
+	return b.String()
+}
+
+// canQuote reports whether value can be written with q without changing meaning.
+func canQuote(q Quote, value string) bool {
+	switch q {
+	case Unquoted:
+		if value == "" {
+			return true
+		}
+		if strings.ContainsAny(value, "\n\r") || strings.TrimSpace(value) != value {
+			return false
+		}
+		if c := value[0]; c == '"' || c == '\'' || c == '`' || c == '#' {
+			return false
+		}
+		return !strings.Contains(value, " #") && !strings.Contains(value, "\t#")
+	case Single, Backtick:
+		return !strings.ContainsRune(value, rune(q))
+	case Double:
+		return true
+	}
+	return false
+}
+
+// chooseQuote picks the simplest style that can hold value.
+func chooseQuote(value string) Quote {
+	if canQuote(Unquoted, value) {
+		return Unquoted
+	}
+	return Double
+}
+
+func quoteValue(q Quote, value string) string {
+	switch q {
+	case Single, Backtick:
+		return string(q) + value + string(q)
+	case Double:
+		r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\r", "\\r", "\n", "\\n")
+		return `"` + r.Replace(value) + `"`
+	}
+	return value
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -12,6 +13,48 @@ type Index struct {
 	ScanRoot  string            `json:"scanRoot"`
 	UpdatedAt string            `json:"updatedAt"`
 	Staged    map[string]string `json:"staged"`
+	// Objects maps each staged path to the snapshot of its contents taken by
+	// `sentra add` (see package objects). Indexes written before snapshots
+	// existed have no entries.
+	Objects map[string]string `json:"objects,omitempty"`
+	// Deleted lists tracked paths staged for removal.
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// Empty reports whether nothing is staged.
+func (idx Index) Empty() bool {
+	return len(idx.Staged) == 0 && len(idx.Deleted) == 0
+}
+
+// StageDeletion stages p for removal and unstages any contents for it.
+func (idx *Index) StageDeletion(p string) {
+	delete(idx.Staged, p)
+	delete(idx.Objects, p)
+	for _, d := range idx.Deleted {
+		if d == p {
+			return
+		}
+	}
+	idx.Deleted = append(idx.Deleted, p)
+	sort.Strings(idx.Deleted)
+}
+
+// Unstage drops p from the index, whether staged for contents or deletion.
+// It reports whether anything was staged.
+func (idx *Index) Unstage(p string) bool {
+	_, ok := idx.Staged[p]
+	delete(idx.Staged, p)
+	delete(idx.Objects, p)
+	kept := idx.Deleted[:0]
+	for _, d := range idx.Deleted {
+		if d == p {
+			ok = true
+			continue
+		}
+		kept = append(kept, d)
+	}
+	idx.Deleted = kept
+	return ok
 }
 
 func DefaultPath() (string, error) {
@@ -0,0 +1,117 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgeovany/sentra/cli/internal/auth"
+)
+
+// ErrNotFound is returned when an object is not present in the local store.
+var ErrNotFound = errors.New("object not found")
+
+// Dir returns the root of the local content-addressed object store.
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sentra", "objects"), nil
+}
+
+// Put stores an encrypted copy of plain and returns its id
+// (lowercase hex SHA-256 of the plaintext). Storing the same content twice is a no-op.
+func Put(plain []byte) (string, error) {
+	id := auth.SHA256Hex(plain)
+	p, err := objectPath(id)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(p); err == nil {
+		return id, nil
+	}
+
+	raw, err := auth.SealLocalObject(id, plain)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return "", err
+	}
+	tmpPath := p + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Get returns the plaintext for id and checks that it still hashes to id.
+func Get(id string) ([]byte, error) {
+	id = strings.TrimSpace(id)
+	p, err := objectPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+
+	plain, err := auth.OpenLocalObject(id, raw)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt object %s: %w", id, err)
+	}
+	if got := auth.SHA256Hex(plain); got != id {
+		return nil, fmt.Errorf("object %s is corrupt (sha256 %s)", id, got)
+	}
+	return plain, nil
+}
+
+// Has reports whether id is present in the local store. It does not decrypt.
+func Has(id string) bool {
+	p, err := objectPath(strings.TrimSpace(id))
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+func objectPath(id string) (string, error) {
+	if !isObjectID(id) {
+		return "", fmt.Errorf("invalid object id: %q", id)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	// Fan out by prefix like git does, to keep directories small.
+	return filepath.Join(dir, id[:2], id[2:]), nil
+}
+
+func isObjectID(id string) bool {
+	if len(id) != 64 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheVersion is bumped whenever the hash or the cache layout changes, so
+// stale caches are dropped instead of trusted.
+const cacheVersion = 1
+
+// racyWindow is how recent an mtime must be for its entry not to be cached:
+// a file edited again within the same timestamp tick would look unchanged.
+const racyWindow = 2 * time.Second
+
+// Cache remembers what the last scan saw: directory listings keyed on the
+// directory's mtime, and env file hashes keyed on mtime, size and inode.
+// Unchanged directories are not re-read and unchanged files not re-hashed.
+type Cache struct {
+	path string
+	mu   sync.Mutex
+	prev cacheFile
+	next cacheFile
+	// fresh is the cut-off: stats newer than it are never cached.
+	fresh time.Time
+}
+
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Dirs    map[string]cachedDir  `json:"dirs"`
+	Files   map[string]cachedStat `json:"files"`
+}
+
+type cachedDir struct {
+	ModTime int64         `json:"mtime"`
+	Entries []cachedEntry `json:"entries"`
+}
+
+type cachedEntry struct {
+	Name string `json:"name"`
+	Dir  bool   `json:"dir,omitempty"`
+}
+
+type cachedStat struct {
+	ModTime int64  `json:"mtime"`
+	Size    int64  `json:"size"`
+	Inode   uint64 `json:"inode,omitempty"`
+	Rel     string `json:"rel"`
+	Hash    string `json:"hash"`
+}
+
+func DefaultCachePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".sentra", "scan-cache.json"), nil
+}
+
+// NewCache returns an empty cache that Save writes to p. Scanning with it
+// reads everything, then replaces whatever was cached before.
+func NewCache(p string) *Cache {
+	return &Cache{
+		path:  p,
+		next:  cacheFile{Version: cacheVersion, Dirs: map[string]cachedDir{}, Files: map[string]cachedStat{}},
+		fresh: time.Now().Add(-racyWindow),
+	}
+}
+
+// OpenCache loads the cache at p. A missing, unreadable or outdated cache is
+// treated as empty: the scan is just slower.
+func OpenCache(p string) *Cache {
+	c := NewCache(p)
+	if b, err := os.ReadFile(p); err == nil {
+		var prev cacheFile
+		if json.Unmarshal(b, &prev) == nil && prev.Version == cacheVersion {
+			c.prev = prev
+		}
+	}
+	return c
+}
+
+// Save writes what this scan saw, dropping entries for paths it no longer
+// reached.
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	b, err := json.Marshal(c.next)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// readDir lists dir, reusing the last listing if the directory's mtime has
+// not changed. A nil cache always reads the directory.
+func (c *Cache) readDir(dir string) ([]cachedEntry, error) {
+	if c == nil {
+		return readDirEntries(dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	mtime := info.ModTime().UnixNano()
+
+	c.mu.Lock()
+	hit, ok := c.prev.Dirs[dir]
+	c.mu.Unlock()
+	entries := hit.Entries
+	if !ok || hit.ModTime != mtime {
+		if entries, err = readDirEntries(dir); err != nil {
+			return nil, err
+		}
+	}
+	if info.ModTime().Before(c.fresh) {
+		c.mu.Lock()
+		c.next.Dirs[dir] = cachedDir{ModTime: mtime, Entries: entries}
+		c.mu.Unlock()
+	}
+	return entries, nil
+}
+
+// hashFile returns HashEnv for the file at fullPath, reusing the last hash
+// if its mtime, size and inode have not changed.
+func (c *Cache) hashFile(relPathFromProject string, fullPath string) (string, error) {
+	if c == nil {
+		return hashEnvFile(relPathFromProject, fullPath)
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", err
+	}
+	st := cachedStat{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Inode:   inode(info),
+		Rel:     relPathFromProject,
+	}
+
+	c.mu.Lock()
+	hit, ok := c.prev.Files[fullPath]
+	c.mu.Unlock()
+	if ok && hit.ModTime == st.ModTime && hit.Size == st.Size && hit.Inode == st.Inode && hit.Rel == st.Rel && hit.Hash != "" {
+		st.Hash = hit.Hash
+	} else if st.Hash, err = hashEnvFile(relPathFromProject, fullPath); err != nil {
+		return "", err
+	}
+	if info.ModTime().Before(c.fresh) {
+		c.mu.Lock()
+		c.next.Files[fullPath] = st
+		c.mu.Unlock()
+	}
+	return st.Hash, nil
+}
+
+func readDirEntries(dir string) ([]cachedEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]cachedEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, cachedEntry{Name: e.Name(), Dir: e.Type()&fs.ModeDir != 0})
+	}
+	return out, nil
+}
@@ -0,0 +1,435 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// configFileNames are tried in order; the first one found is used.
+var configFileNames = []string{".sentra.yml", ".sentra.yaml", ".sentra.toml"}
+
+// envLikeName is what an included file must be called: the server only
+// accepts these names (see the push schema).
+var envLikeName = regexp.MustCompile(`^(?:[A-Za-z0-9_-][A-Za-z0-9._-]*)?\.env(?:\.[A-Za-z0-9._-]+)*$`)
+
+// Config holds env-file detection rules from a .sentra.yml (or .sentra.toml)
+// at the scan root or a project root:
+//
+//	environments: [qa, preview]   # also detect .env.qa, .env.preview.local, ...
+//	include:                      # globs, relative to the project or a file name
+//	  - config/secrets.env
+//	exclude:
+//	  - .env.test
+//
+// Exclude wins over include and the built-in names.
+//
+//	cipher: age-v1                # how push encrypts the files (default sentra-v2)
+//	legacy_ciphers: [legacy]      # unbound ciphers sync still accepts
+//
+// A project's cipher overrides the scan root's; legacy ciphers add up.
+//
+// Two keys shape project discovery and are only read from the scan root:
+//
+//	submodules: true   # also find submodules and repos nested in a project
+//	worktrees: main    # count a linked worktree as its main checkout
+//
+// By default each worktree is a project of its own.
+type Config struct {
+	Environments []string
+	Include      []string
+	Exclude      []string
+	Cipher       string
+	// LegacyCiphers are ciphers not bound to their path that reading still
+	// accepts. Which names are valid is up to the caller.
+	LegacyCiphers []string
+	Submodules    bool
+	Worktrees     string
+}
+
+const (
+	worktreesSeparate = "separate"
+	worktreesMain     = "main"
+)
+
+// LoadConfig reads the config file in dir, if any.
+func LoadConfig(dir string) (Config, bool, error) {
+	for _, name := range configFileNames {
+		p := filepath.Join(dir, name)
+		b, err := os.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return Config{}, false, err
+		}
+		var cfg Config
+		if strings.HasSuffix(name, ".toml") {
+			cfg, err = parseTOMLConfig(b)
+		} else {
+			cfg, err = parseYAMLConfig(b)
+		}
+		if err != nil {
+			return Config{}, false, fmt.Errorf("%s: %w", p, err)
+		}
+		return cfg, true, nil
+	}
+	return Config{}, false, nil
+}
+
+// ProjectConfig is the config that applies to the project at projectRoot:
+// its own on top of scanRoot's.
+func ProjectConfig(scanRoot, projectRoot string) (Config, error) {
+	cfg, _, err := LoadConfig(scanRoot)
+	if err != nil {
+		return Config{}, err
+	}
+	if filepath.Clean(projectRoot) == filepath.Clean(scanRoot) {
+		return cfg, nil
+	}
+	projectCfg, _, err := LoadConfig(projectRoot)
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg.merge(projectCfg), nil
+}
+
+// merge returns c with o's rules added (a project config on top of the scan
+// root's). Discovery settings stay c's: projects are found before their own
+// config is read.
+func (c Config) merge(o Config) Config {
+	cipher := c.Cipher
+	if o.Cipher != "" {
+		cipher = o.Cipher
+	}
+	return Config{
+		Environments:  append(append([]string(nil), c.Environments...), o.Environments...),
+		Include:       append(append([]string(nil), c.Include...), o.Include...),
+		Exclude:       append(append([]string(nil), c.Exclude...), o.Exclude...),
+		Cipher:        cipher,
+		LegacyCiphers: append(append([]string(nil), c.LegacyCiphers...), o.LegacyCiphers...),
+		Submodules:    c.Submodules,
+		Worktrees:     c.Worktrees,
+	}
+}
+
+// SetConfigValue sets a scalar key in dir's config file, replacing the key's
+// line if there is one, and creates a .sentra.yml if dir has no config. It
+// returns the file written.
+func SetConfigValue(dir, key, value string) (string, error) {
+	p := filepath.Join(dir, configFileNames[0])
+	var lines []string
+	for _, name := range configFileNames {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			p = filepath.Join(dir, name)
+			lines = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	toml := strings.HasSuffix(p, ".toml")
+	sep, line := ":", key+": "+value
+	if toml {
+		sep, line = "=", key+" = "+strconv.Quote(value)
+	}
+	replaced := false
+	for i, l := range lines {
+		k, _, ok := strings.Cut(stripComment(l), sep)
+		if ok && l == strings.TrimLeft(l, " \t") && strings.TrimSpace(k) == key {
+			lines[i] = line
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+	out := []byte(strings.Join(lines, "\n") + "\n")
+
+	// Refuse to write a file that no longer parses.
+	var err error
+	if toml {
+		_, err = parseTOMLConfig(out)
+	} else {
+		_, err = parseYAMLConfig(out)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", p, err)
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(p); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(p, out, mode); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// isEnvFile reports whether the file at rel (relative to the project) is an
+// env file under these rules.
+func (c Config) isEnvFile(rel string) bool {
+	name := path.Base(rel)
+	if matchConfigGlobs(c.Exclude, rel) {
+		return false
+	}
+	if isEnvFileName(name, c.Environments) {
+		return true
+	}
+	return envLikeName.MatchString(name) && matchConfigGlobs(c.Include, rel)
+}
+
+func matchConfigGlobs(globs []string, rel string) bool {
+	base := path.Base(rel)
+	for _, g := range globs {
+		if ok, _ := path.Match(g, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) set(key string, values []string, line int) error {
+	switch key {
+	case "submodules":
+		if len(values) != 1 || (values[0] != "true" && values[0] != "false") {
+			return fmt.Errorf("line %d: submodules must be true or false", line)
+		}
+		c.Submodules = values[0] == "true"
+		return nil
+	case "worktrees":
+		if len(values) != 1 || (values[0] != worktreesSeparate && values[0] != worktreesMain) {
+			return fmt.Errorf("line %d: worktrees must be %s or %s", line, worktreesSeparate, worktreesMain)
+		}
+		c.Worktrees = values[0]
+		return nil
+	case "cipher":
+		// Which names are valid is up to the caller.
+		if len(values) != 1 || values[0] == "" {
+			return fmt.Errorf("line %d: cipher must be a single name", line)
+		}
+		c.Cipher = values[0]
+		return nil
+	}
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("line %d: empty value for %s", line, key)
+		}
+		if _, err := path.Match(v, ""); err != nil {
+			return fmt.Errorf("line %d: invalid pattern %q", line, v)
+		}
+	}
+	switch key {
+	case "environments":
+		for _, v := range values {
+			if strings.ContainsAny(v, "./*?[") {
+				return fmt.Errorf("line %d: invalid environment name %q", line, v)
+			}
+		}
+		c.Environments = append(c.Environments, values...)
+	case "include":
+		c.Include = append(c.Include, values...)
+	case "exclude":
+		c.Exclude = append(c.Exclude, values...)
+	case "legacy_ciphers":
+		c.LegacyCiphers = append(c.LegacyCiphers, values...)
+	default:
+		return fmt.Errorf("line %d: unknown key %q (expected environments, include, exclude, cipher, legacy_ciphers, submodules or worktrees)", line, key)
+	}
+	return nil
+}
+
+// parseYAMLConfig reads the small subset of YAML a config needs: top-level
+// keys holding a scalar, a [flow, list] or a block list of "- item" lines.
+func parseYAMLConfig(b []byte) (Config, error) {
+	var cfg Config
+	key := ""
+	for i, raw := range strings.Split(string(b), "\n") {
+		n := i + 1
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if key == "" {
+				return Config{}, fmt.Errorf("line %d: list item outside a key", n)
+			}
+			v, err := unquoteConfigValue(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+			if err != nil {
+				return Config{}, fmt.Errorf("line %d: %w", n, err)
+			}
+			if err := cfg.set(key, []string{v}, n); err != nil {
+				return Config{}, err
+			}
+			continue
+		}
+		if line != trimmed {
+			return Config{}, fmt.Errorf("line %d: unexpected indentation", n)
+		}
+
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return Config{}, fmt.Errorf("line %d: expected key: value", n)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if v == "" {
+			// A block list follows.
+			key = k
+			if err := cfg.set(k, nil, n); err != nil {
+				return Config{}, err
+			}
+			continue
+		}
+		key = ""
+		values, err := parseConfigValue(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("line %d: %w", n, err)
+		}
+		if err := cfg.set(k, values, n); err != nil {
+			return Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// parseTOMLConfig reads top-level `key = "value"` and `key = [...]` lines;
+// arrays may span several lines.
+func parseTOMLConfig(b []byte) (Config, error) {
+	var cfg Config
+	lines := strings.Split(string(b), "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return Config{}, fmt.Errorf("line %d: tables are not supported", n)
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("line %d: expected key = value", n)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		for strings.HasPrefix(v, "[") && !strings.HasSuffix(v, "]") {
+			if i+1 >= len(lines) {
+				return Config{}, fmt.Errorf("line %d: unterminated array", n)
+			}
+			i++
+			v += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+		values, err := parseConfigValue(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("line %d: %w", n, err)
+		}
+		if err := cfg.set(k, values, n); err != nil {
+			return Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// parseConfigValue reads a scalar or a [a, "b"] list.
+func parseConfigValue(v string) ([]string, error) {
+	if !strings.HasPrefix(v, "[") {
+		s, err := unquoteConfigValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+	if !strings.HasSuffix(v, "]") {
+		return nil, fmt.Errorf("unterminated list")
+	}
+	inner := strings.TrimSpace(v[1 : len(v)-1])
+	var out []string
+	for inner != "" {
+		item := inner
+		rest := ""
+		if q := inner[0]; q == '"' || q == '\'' {
+			end := closingQuote(inner)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			item, rest = inner[:end+1], inner[end+1:]
+			rest = strings.TrimSpace(rest)
+			if rest != "" && !strings.HasPrefix(rest, ",") {
+				return nil, fmt.Errorf("expected , after %s", item)
+			}
+		} else if j := strings.IndexByte(inner, ','); j >= 0 {
+			item, rest = inner[:j], inner[j:]
+		}
+		s, err := unquoteConfigValue(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+		inner = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+	}
+	return out, nil
+}
+
+func unquoteConfigValue(v string) (string, error) {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return strconv.Unquote(v)
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'"), nil
+	}
+	if strings.ContainsAny(v, `"'`) {
+		return "", fmt.Errorf("malformed string %s", v)
+	}
+	return v, nil
+}
+
+// closingQuote returns the index of the quote closing the string s starts
+// with, or -1.
+func closingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment drops a # comment that starts the line or follows whitespace,
+// outside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
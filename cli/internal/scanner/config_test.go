@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAMLConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "empty",
+			src:  "",
+		},
+		{
+			name: "flow lists",
+			src:  "environments: [qa, preview]\ninclude: [\"config/*.env\", 'secrets.env']\nexclude: []\n",
+			want: Config{Environments: []string{"qa", "preview"}, Include: []string{"config/*.env", "secrets.env"}},
+		},
+		{
+			name: "block lists",
+			src:  "include:\n  - config/secrets.env\n  - \"a, b.env\"\nexclude:\n- .env.test\n",
+			want: Config{Include: []string{"config/secrets.env", "a, b.env"}, Exclude: []string{".env.test"}},
+		},
+		{
+			name: "scalars",
+			src:  "environments: qa\ncipher: age-v1\nsubmodules: true\nworktrees: main\n",
+			want: Config{Environments: []string{"qa"}, Cipher: "age-v1", Submodules: true, Worktrees: "main"},
+		},
+		{
+			name: "legacy ciphers",
+			src:  "legacy_ciphers: [legacy, age-v1]\n",
+			want: Config{LegacyCiphers: []string{"legacy", "age-v1"}},
+		},
+		{
+			name: "repeated keys add up",
+			src:  "exclude: [.env.a]\nexclude:\n  - .env.b\n",
+			want: Config{Exclude: []string{".env.a", ".env.b"}},
+		},
+		{
+			name: "comments and blank lines",
+			src:  "# rules\n\nenvironments: [qa] # trailing\ninclude:\n  # between items\n  - \"x#y.env\"   # after a quoted item\n  - a#b.env\n",
+			want: Config{Environments: []string{"qa"}, Include: []string{"x#y.env", "a#b.env"}},
+		},
+		{
+			name: "quoting",
+			src:  "include: [\"with \\\"escape\\\".env\", 'it''s.env', \"comma, inside.env\"]\n",
+			want: Config{Include: []string{`with "escape".env`, "it's.env", "comma, inside.env"}},
+		},
+		{
+			name: "crlf",
+			src:  "environments: [qa]\r\nexclude:\r\n  - .env.test\r\n",
+			want: Config{Environments: []string{"qa"}, Exclude: []string{".env.test"}},
+		},
+		{name: "unknown key", src: "environment: [qa]\n", wantErr: `line 1: unknown key "environment"`},
+		{name: "item outside a key", src: "- .env\n", wantErr: "line 1: list item outside a key"},
+		{name: "item after a scalar", src: "cipher: age-v1\n  - .env\n", wantErr: "line 2: list item outside a key"},
+		{name: "indented key", src: "include: [a]\n  exclude: [b]\n", wantErr: "line 2: unexpected indentation"},
+		{name: "missing colon", src: "include\n", wantErr: "line 1: expected key: value"},
+		{name: "unterminated list", src: "include: [a, b\n", wantErr: "line 1: unterminated list"},
+		{name: "unterminated string", src: "include: [\"a]\n", wantErr: "line 1: unterminated string"},
+		{name: "junk after quoted item", src: "include: [\"a\" b]\n", wantErr: `expected , after "a"`},
+		{name: "malformed string", src: "include: a\"b\n", wantErr: "malformed string"},
+		{name: "empty item", src: "include:\n  -\n", wantErr: "line 2: empty value for include"},
+		{name: "bad pattern", src: "exclude: [\"[\"]\n", wantErr: `line 1: invalid pattern "["`},
+		{name: "bad environment", src: "environments: [qa.local]\n", wantErr: `invalid environment name "qa.local"`},
+		{name: "bad submodules", src: "submodules: yes\n", wantErr: "submodules must be true or false"},
+		{name: "bad worktrees", src: "worktrees: all\n", wantErr: "worktrees must be separate or main"},
+		{name: "cipher list", src: "cipher: [a, b]\n", wantErr: "cipher must be a single name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAMLConfig([]byte(tt.src))
+			checkConfig(t, got, err, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func TestParseTOMLConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "arrays and scalars",
+			src:  "environments = [\"qa\", \"preview\"]\ncipher = \"age-v1\"\nsubmodules = true\nworktrees = 'main'\n",
+			want: Config{Environments: []string{"qa", "preview"}, Cipher: "age-v1", Submodules: true, Worktrees: "main"},
+		},
+		{
+			name: "multiline array with comments",
+			src:  "# rules\ninclude = [\n  \"config/*.env\", # app config\n  \"secrets.env\",\n]\n",
+			want: Config{Include: []string{"config/*.env", "secrets.env"}},
+		},
+		{name: "table", src: "[scan]\ninclude = [\"a\"]\n", wantErr: "line 1: tables are not supported"},
+		{name: "missing equals", src: "include [\"a\"]\n", wantErr: "line 1: expected key = value"},
+		{name: "unterminated array", src: "include = [\n  \"a\",\n", wantErr: "line 1: unterminated array"},
+		{name: "unknown key", src: "includes = [\"a\"]\n", wantErr: `unknown key "includes"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOMLConfig([]byte(tt.src))
+			checkConfig(t, got, err, tt.want, tt.wantErr)
+		})
+	}
+}
+
+func checkConfig(t *testing.T, got Config, err error, want Config, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("err = %v, want %q", err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("config = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsEnvFile(t *testing.T) {
+	cfg := Config{
+		Environments: []string{"qa"},
+		Include:      []string{"config/*.env", "secrets.env", "notes.txt"},
+		Exclude:      []string{".env.test", "legacy/*"},
+	}
+	tests := map[string]bool{
+		".env":               true,
+		".env.local":         true,
+		".env.qa":            true,
+		".env.qa.local":      true,
+		".env.preview":       false,
+		".env.test":          false, // excluded, though built in
+		"config/app.env":     true,
+		"config/deep/x.env":  false, // * does not cross directories
+		"sub/secrets.env":    true,  // matched by file name
+		"legacy/.env":        false,
+		"notes.txt":          false, // included, but not an env-like name
+		"apps/web/.env.qa":   true,
+		"apps/web/.env.test": false,
+	}
+	for rel, want := range tests {
+		if got := cfg.isEnvFile(rel); got != want {
+			t.Errorf("isEnvFile(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestProjectConfig(t *testing.T) {
+	scanRoot := t.TempDir()
+	project := filepath.Join(scanRoot, "api")
+	writeConfig(t, filepath.Join(scanRoot, ".sentra.yml"), "environments: [qa]\ncipher: age-v1\nlegacy_ciphers: [legacy]\nsubmodules: true\n")
+	writeConfig(t, filepath.Join(project, ".sentra.toml"), "environments = [\"preview\"]\ncipher = \"sentra-v2\"\nlegacy_ciphers = [\"age-v1\"]\nsubmodules = false\n")
+
+	got, err := ProjectConfig(scanRoot, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rules add up, the project's cipher wins, discovery stays the root's.
+	want := Config{Environments: []string{"qa", "preview"}, Cipher: "sentra-v2", LegacyCiphers: []string{"legacy", "age-v1"}, Submodules: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("config = %+v, want %+v", got, want)
+	}
+
+	// .sentra.yml is preferred over .sentra.toml in the same directory.
+	writeConfig(t, filepath.Join(project, ".sentra.yml"), "exclude: [.env]\n")
+	got, err = ProjectConfig(scanRoot, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cipher != "age-v1" || !reflect.DeepEqual(got.Exclude, []string{".env"}) {
+		t.Fatalf("config = %+v", got)
+	}
+
+	writeConfig(t, filepath.Join(project, ".sentra.yml"), "exclude: [\n")
+	if _, err := ProjectConfig(scanRoot, project); err == nil || !strings.Contains(err.Error(), ".sentra.yml: line 1") {
+		t.Fatalf("err = %v, want the file and line", err)
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	dir := t.TempDir()
+	p, err := SetConfigValue(dir, "cipher", "age-v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(p) != ".sentra.yml" {
+		t.Fatalf("created %s", p)
+	}
+	writeConfig(t, p, "# top\ncipher: sentra-v2 # old\ninclude:\n  - a.env\n")
+	if _, err := SetConfigValue(dir, "cipher", "age-v1"); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(p)
+	if want := "# top\ncipher: age-v1\ninclude:\n  - a.env\n"; string(b) != want {
+		t.Fatalf("%s = %q, want %q", p, b, want)
+	}
+
+	tomlDir := t.TempDir()
+	writeConfig(t, filepath.Join(tomlDir, ".sentra.toml"), "include = [\"a.env\"]\n")
+	p, err = SetConfigValue(tomlDir, "cipher", "age-v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = os.ReadFile(p)
+	if want := "include = [\"a.env\"]\ncipher = \"age-v1\"\n"; string(b) != want {
+		t.Fatalf("%s = %q, want %q", p, b, want)
+	}
+
+	if _, err := SetConfigValue(dir, "worktrees", "all"); err == nil {
+		t.Fatal("wrote an invalid value")
+	}
+}
+
+func writeConfig(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
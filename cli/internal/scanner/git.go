@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitRepo is a repository found by findProjectRoots.
+type gitRepo struct {
+	root string
+	// mainRoot is the main checkout of a linked worktree.
+	mainRoot  string
+	submodule bool
+}
+
+// inspectGitEntry reports whether dir is a repository, given that it holds a
+// .git entry. A .git directory is a plain repository. A .git file points at
+// the real git dir with a "gitdir: <path>" line: linked worktrees point into
+// <main>/.git/worktrees/, submodules into <super>/.git/modules/.
+func inspectGitEntry(dir string, isDir bool) (gitRepo, bool) {
+	repo := gitRepo{root: dir}
+	if isDir {
+		return repo, true
+	}
+	gitDir, ok := readGitDirPointer(dir)
+	if !ok {
+		return gitRepo{}, false
+	}
+
+	if common, ok := readCommonDir(gitDir); ok {
+		// A bare repository has no main checkout to map to.
+		if filepath.Base(common) == ".git" {
+			repo.mainRoot = filepath.Dir(common)
+		}
+		return repo, true
+	}
+
+	slashed := filepath.ToSlash(gitDir)
+	repo.submodule = strings.Contains(slashed, "/.git/modules/")
+	return repo, true
+}
+
+// readGitDirPointer reads the gitdir line of dir/.git, resolving a relative
+// path against dir. A file without one is not a repository.
+func readGitDirPointer(dir string) (string, bool) {
+	b, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return "", false
+	}
+	line, _, _ := strings.Cut(string(b), "\n")
+	p, ok := strings.CutPrefix(strings.TrimSpace(line), "gitdir:")
+	if !ok {
+		return "", false
+	}
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p), true
+}
+
+// readCommonDir reads the commondir file with which a worktree's git dir
+// names the shared one.
+func readCommonDir(gitDir string) (string, bool) {
+	b, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return "", false
+	}
+	common := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return filepath.Clean(common), true
+}
+
+// OriginURL returns the url of the origin remote of the repository at dir,
+// or "" if it has none. It reads the config file directly, the shared one
+// for a linked worktree, rather than running git for every project. Includes
+// and url rewrites (insteadOf) are not applied.
+func OriginURL(dir string) string {
+	gitDir := filepath.Join(dir, ".git")
+	if fi, err := os.Stat(gitDir); err != nil {
+		return ""
+	} else if !fi.IsDir() {
+		var ok bool
+		if gitDir, ok = readGitDirPointer(dir); !ok {
+			return ""
+		}
+	}
+	if common, ok := readCommonDir(gitDir); ok {
+		gitDir = common
+	}
+	b, err := os.ReadFile(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return ""
+	}
+	return parseOriginURL(string(b))
+}
+
+// parseOriginURL finds the first url of [remote "origin"] in a git config.
+func parseOriginURL(src string) string {
+	inOrigin := false
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			header, _, _ := strings.Cut(line[1:], "]")
+			section, sub, _ := strings.Cut(strings.TrimSpace(header), " ")
+			sub = strings.TrimSpace(sub)
+			if strings.HasPrefix(sub, `"`) {
+				sub = strings.Trim(sub, `"`)
+			} else if s, name, ok := strings.Cut(section, "."); ok {
+				// The deprecated [remote.origin] form.
+				section, sub = s, strings.ToLower(name)
+			}
+			inOrigin = strings.EqualFold(section, "remote") && sub == "origin"
+			continue
+		}
+		if !inOrigin {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "url") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if end := strings.Index(value[1:], `"`); end >= 0 {
+				return value[1 : end+1]
+			}
+			return strings.Trim(value, `"`)
+		}
+		if i := strings.IndexAny(value, "#;"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOriginURL(t *testing.T) {
+	root := t.TempDir()
+	api := filepath.Join(root, "api")
+	touch(t, filepath.Join(api, ".git", "config"), "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = git@github.com:other/api.git\n[remote \"origin\"]\n\turl = git@github.com:acme/api.git\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n")
+	// A linked worktree reads the shared config.
+	linkWorktree(t, api, filepath.Join(root, "api-feature"), "api-feature")
+	// A submodule has a config of its own under the superproject's.
+	modules := filepath.Join(api, ".git", "modules", "auth")
+	touch(t, filepath.Join(modules, "config"), "[remote \"origin\"]\n\turl = https://github.com/acme/auth\n")
+	touch(t, filepath.Join(api, "auth", ".git"), "gitdir: ../.git/modules/auth\n")
+	gitDir(t, filepath.Join(root, "local"))
+
+	for dir, want := range map[string]string{
+		"api":          "git@github.com:acme/api.git",
+		"api-feature":  "git@github.com:acme/api.git",
+		"api/auth":     "https://github.com/acme/auth",
+		"local":        "",
+		"not-a-repo":   "",
+		"api/.git/foo": "",
+	} {
+		if got := OriginURL(filepath.Join(root, filepath.FromSlash(dir))); got != want {
+			t.Errorf("%s: origin = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestParseOriginURL(t *testing.T) {
+	for _, tc := range []struct {
+		name, src, want string
+	}{
+		{name: "none", src: "[core]\n\tbare = false\n", want: ""},
+		{name: "first url wins", src: "[remote \"origin\"]\n\turl = a\n\turl = b\n", want: "a"},
+		{name: "case of section and key", src: "[Remote \"origin\"]\n\tURL = a\n", want: "a"},
+		{name: "subsection is case sensitive", src: "[remote \"Origin\"]\n\turl = a\n", want: ""},
+		{name: "deprecated form", src: "[remote.Origin]\n\turl = a\n", want: "a"},
+		{name: "quoted with comment", src: "[remote \"origin\"] # main\n\turl = \"a b\" ; note\n", want: "a b"},
+		{name: "trailing comment", src: "[remote \"origin\"]\n\turl = a # note\n", want: "a"},
+		{name: "after another section", src: "[remote \"origin\"]\n[branch \"main\"]\n\turl = a\n", want: ""},
+	} {
+		if got := parseOriginURL(tc.src); got != tc.want {
+			t.Errorf("%s: %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
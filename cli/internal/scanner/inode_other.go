@@ -0,0 +1,10 @@
+//go:build !unix
+
+package scanner
+
+import "os"
+
+// inode is not available here; the cache relies on mtime and size alone.
+func inode(os.FileInfo) uint64 {
+	return 0
+}
@@ -1,13 +1,16 @@
 package scanner
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 )
 
 var defaultIgnoredDirs = map[string]struct{}{
@@ -20,7 +23,30 @@ var defaultIgnoredDirs = map[string]struct{}{
 	"vendor":       {},
 }
 
+// Options tunes a scan.
+type Options struct {
+	// Workers bounds how many directories are read, and projects scanned, at
+	// once. Zero means one per CPU.
+	Workers int
+	// Cache, if set, skips re-reading unchanged directories and re-hashing
+	// unchanged files. The caller saves it after the scan.
+	Cache *Cache
+}
+
+func (o Options) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
 func Scan(scanRoot string) ([]Project, error) {
+	return ScanContext(context.Background(), scanRoot, Options{})
+}
+
+// ScanContext is Scan spread over a pool of workers. Results are in the same
+// order whatever the pool size; it stops early once ctx is cancelled.
+func ScanContext(ctx context.Context, scanRoot string, opts Options) ([]Project, error) {
 	info, err := os.Stat(scanRoot)
 	if err != nil {
 		return nil, err
@@ -29,74 +55,174 @@ func Scan(scanRoot string) ([]Project, error) {
 		return nil, errors.New("scan root is not a directory")
 	}
 
-	projectRoots, err := findProjectRoots(scanRoot)
+	rootCfg, _, err := LoadConfig(scanRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := findProjectRoots(ctx, scanRoot, opts.workers(), opts.Cache, rootCfg.Submodules)
 	if err != nil {
 		return nil, err
 	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].root < repos[j].root })
 
-	sort.Strings(projectRoots)
+	// Every repository found is left out of the projects it is nested in.
+	nested := make(map[string]bool, len(repos))
+	for _, r := range repos {
+		nested[r.root] = true
+	}
+	// A worktree folded into its main checkout is still scanned: its files
+	// count as the checkout's (see Project.Folded).
+	folded := make(map[string]bool)
+	if rootCfg.Worktrees == worktreesMain {
+		for _, r := range repos {
+			if r.mainRoot != "" && nested[r.mainRoot] {
+				folded[r.root] = true
+			}
+		}
+	}
 
-	projects := make([]Project, 0, len(projectRoots))
-	for _, root := range projectRoots {
-		envFiles, err := scanProjectEnvFiles(root)
+	projects := make([]Project, len(repos))
+	errs := make([]error, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.workers(), len(repos)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				root := repos[i].root
+				cfg := rootCfg
+				if root != scanRoot {
+					projectCfg, _, err := LoadConfig(root)
+					if err != nil {
+						errs[i] = err
+						continue
+					}
+					cfg = rootCfg.merge(projectCfg)
+				}
+				envFiles, err := scanProjectEnvFiles(ctx, root, cfg, opts.Cache, nested)
+				projects[i] = Project{RootPath: root, EnvFiles: envFiles, MainRoot: repos[i].mainRoot, Folded: folded[root], Submodule: repos[i].submodule}
+				errs[i] = err
+			}
+		}()
+	}
+	for i := range repos {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Report the first failing project, as a sequential scan would.
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
-		projects = append(projects, Project{RootPath: root, EnvFiles: envFiles})
 	}
-
 	return projects, nil
 }
 
-func findProjectRoots(scanRoot string) ([]string, error) {
-	var roots []string
+// findProjectRoots walks scanRoot for git repositories: directories holding
+// a .git directory, or a .git file pointing elsewhere. It does not look
+// inside a repository unless descend is set. Subdirectories are handed to
+// another goroutine while fewer than workers are busy, and walked inline
+// otherwise.
+func findProjectRoots(ctx context.Context, scanRoot string, workers int, cache *Cache, descend bool) ([]gitRepo, error) {
+	var (
+		mu       sync.Mutex
+		roots    []gitRepo
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, max(workers-1, 0))
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
 
-	var walk func(dir string) error
-	walk = func(dir string) error {
-		entries, err := os.ReadDir(dir)
+	var walk func(dir string)
+	walk = func(dir string) {
+		if ctx.Err() != nil {
+			return
+		}
+		entries, err := cache.readDir(dir)
 		if err != nil {
-			return err
+			fail(err)
+			return
 		}
 
-		// If this directory is a git repo root, record it and stop.
+		// If this directory is a git repo root, record it and stop, unless
+		// nested repositories are wanted too.
 		for _, entry := range entries {
-			if entry.IsDir() && entry.Name() == ".git" {
-				roots = append(roots, dir)
-				return nil
+			if entry.Name != ".git" {
+				continue
+			}
+			if repo, ok := inspectGitEntry(dir, entry.Dir); ok {
+				mu.Lock()
+				roots = append(roots, repo)
+				mu.Unlock()
+				if !descend {
+					return
+				}
 			}
+			break
 		}
 
 		for _, entry := range entries {
-			if !entry.IsDir() {
+			if !entry.Dir {
 				continue
 			}
-			name := entry.Name()
+			name := entry.Name
 			if isIgnoredDirName(name) {
 				continue
 			}
 
 			next := filepath.Join(dir, name)
-			if err := walk(next); err != nil {
-				return err
+			select {
+			case sem <- struct{}{}:
+				wg.Add(1)
+				go func() {
+					defer func() { <-sem; wg.Done() }()
+					walk(next)
+				}()
+			default:
+				walk(next)
 			}
 		}
-
-		return nil
 	}
 
-	if err := walk(scanRoot); err != nil {
+	walk(scanRoot)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-
+	if firstErr != nil {
+		return nil, firstErr
+	}
 	return roots, nil
 }
 
-func scanProjectEnvFiles(projectRoot string) ([]EnvFile, error) {
+// scanProjectEnvFiles lists the env files under projectRoot, leaving out
+// the repositories in nested: they are projects of their own.
+func scanProjectEnvFiles(ctx context.Context, projectRoot string, cfg Config, cache *Cache, nested map[string]bool) ([]EnvFile, error) {
 	var envFiles []EnvFile
 	var ignoreStack []gitIgnoreFile
 
 	var walk func(dir string) error
 	walk = func(dir string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		// load .gitignore for this directory
 		ignoreFile, ok, err := loadGitIgnoreFile(dir)
 		if err != nil {
@@ -107,13 +233,13 @@ func scanProjectEnvFiles(projectRoot string) ([]EnvFile, error) {
 			defer func() { ignoreStack = ignoreStack[:len(ignoreStack)-1] }()
 		}
 
-		entries, err := os.ReadDir(dir)
+		entries, err := cache.readDir(dir)
 		if err != nil {
 			return err
 		}
 
 		for _, entry := range entries {
-			name := entry.Name()
+			name := entry.Name
 			fullPath := filepath.Join(dir, name)
 
 			relFromProject, err := filepath.Rel(projectRoot, fullPath)
@@ -122,9 +248,9 @@ func scanProjectEnvFiles(projectRoot string) ([]EnvFile, error) {
 			}
 			relFromProject = filepath.ToSlash(relFromProject)
 
-			isDir := entry.IsDir()
+			isDir := entry.Dir
 			if isDir {
-				if isIgnoredDirName(name) {
+				if isIgnoredDirName(name) || nested[fullPath] {
 					continue
 				}
 				if isIgnoredByGitignore(ignoreStack, fullPath, relFromProject, true) {
@@ -138,8 +264,8 @@ func scanProjectEnvFiles(projectRoot string) ([]EnvFile, error) {
 
 			// Always detect env files, even if gitignored.
 			// Most repos intentionally ignore `.env` files.
-			if isEnvFileName(name) {
-				h, err := hashEnvFile(relFromProject, fullPath)
+			if cfg.isEnvFile(relFromProject) {
+				h, err := cache.hashFile(relFromProject, fullPath)
 				if err != nil {
 					return err
 				}
@@ -170,13 +296,18 @@ func hashEnvFile(relPathFromProject string, filePath string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return HashEnv(relPathFromProject, b), nil
+}
 
+// HashEnv returns the hash Scan reports for an env file with contents b at
+// relPathFromProject, so callers holding the bytes can compare without rescanning.
+func HashEnv(relPathFromProject string, b []byte) string {
 	h := sha256.New()
 	// Hash = content + path relativo
 	h.Write([]byte(relPathFromProject))
 	h.Write([]byte("\n"))
 	h.Write(b)
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func isIgnoredDirName(name string) bool {
@@ -184,13 +315,14 @@ func isIgnoredDirName(name string) bool {
 	return ok
 }
 
-func isEnvFileName(name string) bool {
+func isEnvFileName(name string, extraEnvironments []string) bool {
 	// Only count real env configs.
 	// Accepted:
 	// - .env
 	// - .env.local
 	// - .env.{development,staging,production,test}
 	// - .env.{development,staging,production,test}.local
+	// plus the same for environments named in a .sentra.yml.
 	if name == ".env" {
 		return true
 	}
@@ -217,6 +349,9 @@ func isEnvFileName(name string) bool {
 		"production":  {},
 		"test":        {},
 	}
+	for _, env := range extraEnvironments {
+		allowedBase[env] = struct{}{}
+	}
 
 	// .env.<base>
 	if len(parts) == 1 {
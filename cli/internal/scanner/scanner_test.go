@@ -0,0 +1,194 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// layoutProject is what a test expects of a scanned project, with paths
+// relative to the scan root.
+type layoutProject struct {
+	Root      string
+	Files     []string
+	MainRoot  string
+	Folded    bool
+	Submodule bool
+}
+
+// scanLayout scans scanRoot with one worker and with several, which must
+// agree.
+func scanLayout(t *testing.T, scanRoot string) []layoutProject {
+	t.Helper()
+	var results [][]layoutProject
+	for _, workers := range []int{1, 4} {
+		projects, err := ScanContext(context.Background(), scanRoot, Options{Workers: workers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []layoutProject
+		for _, p := range projects {
+			lp := layoutProject{Root: relTo(t, scanRoot, p.RootPath), Folded: p.Folded, Submodule: p.Submodule}
+			if p.MainRoot != "" {
+				lp.MainRoot = relTo(t, scanRoot, p.MainRoot)
+			}
+			for _, f := range p.EnvFiles {
+				lp.Files = append(lp.Files, f.Path)
+				b, err := os.ReadFile(filepath.Join(p.RootPath, filepath.FromSlash(f.Path)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f.Hash != HashEnv(f.Path, b) {
+					t.Fatalf("%s/%s: hash is not HashEnv of its path in the project", lp.Root, f.Path)
+				}
+			}
+			out = append(out, lp)
+		}
+		results = append(results, out)
+	}
+	if !reflect.DeepEqual(results[0], results[1]) {
+		t.Fatalf("scans differ by worker count:\n%+v\n%+v", results[0], results[1])
+	}
+	return results[0]
+}
+
+func relTo(t *testing.T, base, p string) string {
+	t.Helper()
+	rel, err := filepath.Rel(base, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func touch(t *testing.T, p string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func gitDir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// linkWorktree makes dir a linked worktree of the repository at main, as
+// `git worktree add` does.
+func linkWorktree(t *testing.T, main, dir, name string) {
+	t.Helper()
+	admin := filepath.Join(main, ".git", "worktrees", name)
+	touch(t, filepath.Join(admin, "commondir"), "../..\n")
+	touch(t, filepath.Join(dir, ".git"), "gitdir: "+admin+"\n")
+}
+
+func checkLayout(t *testing.T, got, want []layoutProject) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("projects:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestScanPlainLayout(t *testing.T) {
+	root := t.TempDir()
+	gitDir(t, filepath.Join(root, "api"))
+	touch(t, filepath.Join(root, "api", ".env"), "A=1\n")
+	touch(t, filepath.Join(root, "api", "config", ".env.local"), "B=1\n")
+	touch(t, filepath.Join(root, "api", "node_modules", "x", ".env"), "C=1\n")
+	gitDir(t, filepath.Join(root, "web"))
+	touch(t, filepath.Join(root, "web", ".env.production"), "D=1\n")
+	touch(t, filepath.Join(root, "notes", ".env"), "not a repository\n")
+
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: "api", Files: []string{".env", "config/.env.local"}},
+		{Root: "web", Files: []string{".env.production"}},
+	})
+}
+
+func TestScanOrgRepoLayout(t *testing.T) {
+	root := t.TempDir()
+	for _, repo := range []string{"acme/api", "acme/web", "personal/blog"} {
+		gitDir(t, filepath.Join(root, filepath.FromSlash(repo)))
+		touch(t, filepath.Join(root, filepath.FromSlash(repo), ".env"), "A=1\n")
+	}
+
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: "acme/api", Files: []string{".env"}},
+		{Root: "acme/web", Files: []string{".env"}},
+		{Root: "personal/blog", Files: []string{".env"}},
+	})
+}
+
+func TestScanNestedLayout(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	gitDir(t, app)
+	touch(t, filepath.Join(app, ".env"), "A=1\n")
+	// A submodule, with its git dir in the superproject's .git/modules.
+	touch(t, filepath.Join(app, "libs", "auth", ".git"), "gitdir: ../../.git/modules/libs/auth\n")
+	touch(t, filepath.Join(app, "libs", "auth", ".env"), "B=1\n")
+	// A repository cloned inside the project.
+	gitDir(t, filepath.Join(app, "tools", "seed"))
+	touch(t, filepath.Join(app, "tools", "seed", ".env.test"), "C=1\n")
+
+	// By default scanning stops at the project, which owns every file.
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: "app", Files: []string{".env", "libs/auth/.env", "tools/seed/.env.test"}},
+	})
+
+	touch(t, filepath.Join(root, ".sentra.yml"), "submodules: true\n")
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: "app", Files: []string{".env"}},
+		{Root: "app/libs/auth", Files: []string{".env"}, Submodule: true},
+		{Root: "app/tools/seed", Files: []string{".env.test"}},
+	})
+}
+
+func TestScanWorktreeLayouts(t *testing.T) {
+	root := t.TempDir()
+	api := filepath.Join(root, "api")
+	gitDir(t, api)
+	touch(t, filepath.Join(api, ".env"), "A=main\n")
+	linkWorktree(t, api, filepath.Join(root, "api-feature"), "api-feature")
+	touch(t, filepath.Join(root, "api-feature", ".env"), "A=feature\n")
+	touch(t, filepath.Join(root, "api-feature", ".env.local"), "B=feature\n")
+	// A worktree of a repository outside the scan root.
+	elsewhere := t.TempDir()
+	gitDir(t, filepath.Join(elsewhere, "lib"))
+	linkWorktree(t, filepath.Join(elsewhere, "lib"), filepath.Join(root, "lib-fix"), "lib-fix")
+	touch(t, filepath.Join(root, "lib-fix", ".env"), "C=1\n")
+
+	// Separate (the default): each worktree is a project of its own.
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: "api", Files: []string{".env"}},
+		{Root: "api-feature", Files: []string{".env", ".env.local"}, MainRoot: "api"},
+		{Root: "lib-fix", Files: []string{".env"}, MainRoot: relTo(t, root, filepath.Join(elsewhere, "lib"))},
+	})
+
+	// Main: the worktree is folded into its checkout but keeps its files. A
+	// worktree whose checkout was not scanned stays a project.
+	touch(t, filepath.Join(root, ".sentra.yml"), "worktrees: main\n")
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: "api", Files: []string{".env"}},
+		{Root: "api-feature", Files: []string{".env", ".env.local"}, MainRoot: "api", Folded: true},
+		{Root: "lib-fix", Files: []string{".env"}, MainRoot: relTo(t, root, filepath.Join(elsewhere, "lib"))},
+	})
+}
+
+func TestScanRootIsProject(t *testing.T) {
+	root := t.TempDir()
+	gitDir(t, root)
+	touch(t, filepath.Join(root, ".env"), "A=1\n")
+	touch(t, filepath.Join(root, "services", "api", ".env"), "B=1\n")
+
+	checkLayout(t, scanLayout(t, root), []layoutProject{
+		{Root: ".", Files: []string{".env", "services/api/.env"}},
+	})
+}
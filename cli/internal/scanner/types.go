@@ -8,4 +8,11 @@ type EnvFile struct {
 type Project struct {
 	RootPath string    `json:"rootPath"`
 	EnvFiles []EnvFile `json:"envFiles"`
+	// MainRoot is set for a linked worktree: the main checkout it belongs to.
+	MainRoot string `json:"mainRoot,omitempty"`
+	// Folded is set for a worktree counted as its main checkout (worktrees:
+	// main in the scan root's config). The main checkout is in the results
+	// too. EnvFiles are still relative to RootPath.
+	Folded    bool `json:"folded,omitempty"`
+	Submodule bool `json:"submodule,omitempty"`
 }
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// State is the status baseline: for every env file, the scanner hash of the
+// version last committed, pushed or synced on this machine.
 type State struct {
 	ScanRoot string                       `json:"scanRoot"`
 	Projects map[string]map[string]string `json:"projects"`
@@ -74,3 +77,63 @@ func Save(filePath string, state State) error {
 
 	return nil
 }
+
+// rootDir holds the files of a project at the scan root itself, whose full
+// paths have no directory (".env").
+const rootDir = "."
+
+// splitPath splits a full file path into the map keys it is stored under.
+func splitPath(p string) (string, string, bool) {
+	root, rel, ok := strings.Cut(p, "/")
+	if !ok {
+		return rootDir, p, p != ""
+	}
+	return root, rel, root != "" && rel != ""
+}
+
+// Record sets the baseline hash of each full file path ("root/.env").
+func (s *State) Record(files map[string]string) {
+	if s.Projects == nil {
+		s.Projects = map[string]map[string]string{}
+	}
+	for p, hash := range files {
+		root, rel, ok := splitPath(p)
+		if !ok {
+			continue
+		}
+		if s.Projects[root] == nil {
+			s.Projects[root] = map[string]string{}
+		}
+		s.Projects[root][rel] = hash
+	}
+}
+
+// Forget drops full file paths from the baseline, e.g. once their deletion
+// has been committed.
+func (s *State) Forget(paths []string) {
+	for _, p := range paths {
+		root, rel, ok := splitPath(p)
+		if !ok {
+			continue
+		}
+		delete(s.Projects[root], rel)
+		if len(s.Projects[root]) == 0 {
+			delete(s.Projects, root)
+		}
+	}
+}
+
+// Files returns the baseline keyed by full file path ("root/.env").
+func (s State) Files() map[string]string {
+	out := map[string]string{}
+	for root, envs := range s.Projects {
+		for rel, hash := range envs {
+			if root == rootDir {
+				out[rel] = hash
+				continue
+			}
+			out[root+"/"+rel] = hash
+		}
+	}
+	return out
+}
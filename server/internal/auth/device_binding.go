@@ -13,6 +13,7 @@ import (
 
 const (
 	deviceSigVersion = "v2"
+	encKeySigContext = "sentra-enc-pub-key-v1"
 )
 
 func canonicalDeviceMessage(machineID, timestamp, nonce, method, path string, body []byte) []byte {
@@ -78,6 +79,26 @@ func VerifyDeviceSignature(devicePubKeyB64, machineID, timestamp, nonce, method,
 	return nil
 }
 
+// VerifyEncPubKeySignature checks that the device key signed encPubKey for
+// machineID. Clients pin device keys and check this themselves; the server
+// only refuses registrations that carry a bad signature.
+func VerifyEncPubKeySignature(devicePubKeyB64, machineID, encPubKey, sigB64 string) error {
+	pubRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(devicePubKeyB64))
+	if err != nil || len(pubRaw) != ed25519.PublicKeySize {
+		return errors.New("invalid device pubkey")
+	}
+	sigRaw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(sigB64))
+	if err != nil || len(sigRaw) != ed25519.SignatureSize {
+		return errors.New("invalid signature")
+	}
+	// Format: sentra-enc-pub-key-v1\n<machine_id>\n<enc_pub_key>
+	msg := []byte(encKeySigContext + "\n" + strings.TrimSpace(machineID) + "\n" + strings.TrimSpace(encPubKey))
+	if !ed25519.Verify(ed25519.PublicKey(pubRaw), msg, sigRaw) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
 func boolToByte(b bool) byte {
 	if b {
 		return 1
@@ -16,6 +16,8 @@ import (
 
 type ctxKeySignedBody struct{}
 
+type ctxKeySignedMachine struct{}
+
 const maxPushBodyBytes = 12 << 20 // 12 MiB
 
 type nonceCache struct {
@@ -125,8 +127,19 @@ func requireDeviceSignature(store repo.MachineStore, next http.Handler) http.Han
 			return
 		}
 
-		r = r.WithContext(context.WithValue(r.Context(), ctxKeySignedBody{}, body))
+		ctx := context.WithValue(r.Context(), ctxKeySignedBody{}, body)
+		ctx = context.WithValue(ctx, ctxKeySignedMachine{}, machineID)
+		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// signedMachineID returns the machine id verified by requireDeviceSignature.
+func signedMachineID(r *http.Request) (string, bool) {
+	v, ok := r.Context().Value(ctxKeySignedMachine{}).(string)
+	if !ok || strings.TrimSpace(v) == "" {
+		return "", false
+	}
+	return v, true
+}
@@ -0,0 +1,237 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mgeovany/sentra/server/internal/auth"
+	"github.com/mgeovany/sentra/server/internal/repo"
+	"github.com/mgeovany/sentra/server/internal/validate"
+)
+
+const (
+	linkRequestTTL = 15 * time.Minute
+	// maxLinkBundleLen bounds the sealed key bundle (base64url). A bundle holds
+	// a handful of 32-byte keys, so this is generous.
+	maxLinkBundleLen = 64 << 10
+)
+
+type createLinkRequest struct {
+	MachineName string `json:"machine_name"`
+	EncPubKey   string `json:"enc_pub_key"`
+}
+
+type approveLinkRequest struct {
+	MachineID string `json:"machine_id"`
+	Bundle    string `json:"bundle"`
+}
+
+type linkBundleResponse struct {
+	Status     string `json:"status"`
+	ApprovedBy string `json:"approved_by,omitempty"`
+	Bundle     string `json:"bundle,omitempty"`
+}
+
+// linksHandler serves /machines/link. Both methods sit behind
+// requireDeviceSignature, so the caller's machine id is verified:
+//   - POST: the calling (new) machine opens a link request for itself.
+//   - GET: a trusted machine lists other machines' pending requests.
+func linksHandler(store repo.LinkStore) http.Handler {
+	if store == nil {
+		store = repo.DisabledLinkStore{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok || strings.TrimSpace(user.ID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		machineID, ok := signedMachineID(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var req createLinkRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			req.MachineName = strings.TrimSpace(req.MachineName)
+			req.EncPubKey = strings.TrimSpace(req.EncPubKey)
+			if validate.MachineName(req.MachineName) != nil || req.EncPubKey == "" || validate.EncPubKeyB64(req.EncPubKey) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			err = store.CreateLink(r.Context(), user.ID, repo.LinkRequest{
+				MachineID:   machineID,
+				MachineName: req.MachineName,
+				EncPubKey:   req.EncPubKey,
+			}, linkRequestTTL)
+			if err != nil {
+				log.Printf("machines/link create failed user_id=%q machine_id=%q err=%v", user.ID, machineID, err)
+				writeLinkStoreError(w, err, "link request failed")
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, "ok")
+
+		case http.MethodGet:
+			links, err := store.ListPendingLinks(r.Context(), user.ID)
+			if err != nil {
+				log.Printf("machines/link list failed user_id=%q err=%v", user.ID, err)
+				writeLinkStoreError(w, err, "link list failed")
+				return
+			}
+			out := make([]repo.LinkRequest, 0, len(links))
+			for _, l := range links {
+				// A machine can't approve its own request.
+				if l.MachineID == machineID {
+					continue
+				}
+				l.Bundle = ""
+				out = append(out, l)
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(out)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// approveLinkHandler stores the key bundle a trusted machine sealed to the
+// requesting machine's encryption key. The server can't read it.
+func approveLinkHandler(store repo.LinkStore) http.Handler {
+	if store == nil {
+		store = repo.DisabledLinkStore{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok || strings.TrimSpace(user.ID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		approver, ok := signedMachineID(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req approveLinkRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req.MachineID = strings.TrimSpace(req.MachineID)
+		req.Bundle = strings.TrimSpace(req.Bundle)
+		if validate.MachineID(req.MachineID) != nil || req.Bundle == "" || len(req.Bundle) > maxLinkBundleLen {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.MachineID == approver {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, "cannot approve own link request")
+			return
+		}
+
+		if err := store.ApproveLink(r.Context(), user.ID, req.MachineID, approver, req.Bundle); err != nil {
+			log.Printf("machines/link approve failed user_id=%q machine_id=%q approver=%q err=%v", user.ID, req.MachineID, approver, err)
+			writeLinkStoreError(w, err, "link approve failed")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "ok")
+	})
+}
+
+// linkBundleHandler lets the requesting machine poll its own link request.
+// Once an approved bundle has been handed out, the request is deleted.
+func linkBundleHandler(store repo.LinkStore) http.Handler {
+	if store == nil {
+		store = repo.DisabledLinkStore{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok || strings.TrimSpace(user.ID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		machineID, ok := signedMachineID(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		link, found, err := store.GetLink(r.Context(), user.ID, machineID)
+		if err != nil {
+			log.Printf("machines/link bundle lookup failed user_id=%q machine_id=%q err=%v", user.ID, machineID, err)
+			writeLinkStoreError(w, err, "link lookup failed")
+			return
+		}
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "no link request")
+			return
+		}
+
+		resp := linkBundleResponse{Status: link.Status}
+		if link.Status == repo.LinkApproved {
+			resp.ApprovedBy = link.ApprovedBy
+			resp.Bundle = link.Bundle
+			if err := store.DeleteLink(r.Context(), user.ID, machineID); err != nil {
+				log.Printf("machines/link cleanup failed user_id=%q machine_id=%q err=%v", user.ID, machineID, err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func writeLinkStoreError(w http.ResponseWriter, err error, publicMsg string) {
+	switch {
+	case errors.Is(err, repo.ErrDBNotConfigured):
+		writeHTTPError(w, http.StatusServiceUnavailable, "db not configured", err)
+	case errors.Is(err, repo.ErrLinkNotFound):
+		writeHTTPError(w, http.StatusNotFound, "no pending link request", err)
+	default:
+		writeHTTPError(w, http.StatusInternalServerError, publicMsg, err)
+	}
+}
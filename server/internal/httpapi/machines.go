@@ -18,6 +18,8 @@ type registerMachineRequest struct {
 	MachineName   string `json:"machine_name"`
 	DevicePubKey  string `json:"device_pub_key"`
 	DeviceKeyType string `json:"device_key_type"`
+	EncPubKey     string `json:"enc_pub_key"`
+	EncPubKeySig  string `json:"enc_pub_key_sig"`
 }
 
 func registerMachineHandler(store repo.MachineStore) http.Handler {
@@ -55,10 +57,19 @@ func registerMachineHandler(store repo.MachineStore) http.Handler {
 		req.MachineName = strings.TrimSpace(req.MachineName)
 		req.DevicePubKey = strings.TrimSpace(req.DevicePubKey)
 		req.DeviceKeyType = strings.TrimSpace(req.DeviceKeyType)
-		if validate.MachineID(req.MachineID) != nil || validate.MachineName(req.MachineName) != nil || validate.DevicePubKeyB64(req.DevicePubKey) != nil {
+		req.EncPubKey = strings.TrimSpace(req.EncPubKey)
+		req.EncPubKeySig = strings.TrimSpace(req.EncPubKeySig)
+		if validate.MachineID(req.MachineID) != nil || validate.MachineName(req.MachineName) != nil || validate.DevicePubKeyB64(req.DevicePubKey) != nil || validate.EncPubKeyB64(req.EncPubKey) != nil || validate.EncPubKeySigB64(req.EncPubKeySig) != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
+		// Other machines only use an encryption key signed by the device key
+		// they pinned; reject a signature that couldn't pass that check.
+		if req.EncPubKeySig != "" && (req.EncPubKey == "" || auth.VerifyEncPubKeySignature(req.DevicePubKey, req.MachineID, req.EncPubKey, req.EncPubKeySig) != nil) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, "invalid enc_pub_key_sig")
+			return
+		}
 		if req.DeviceKeyType == "" {
 			req.DeviceKeyType = "ed25519"
 		}
@@ -116,7 +127,7 @@ func registerMachineHandler(store repo.MachineStore) http.Handler {
 			return
 		}
 
-		err = store.Register(r.Context(), user.ID, req.MachineID, req.MachineName, req.DevicePubKey)
+		err = store.Register(r.Context(), user.ID, req.MachineID, req.MachineName, req.DevicePubKey, req.EncPubKey, req.EncPubKeySig)
 		if err != nil {
 			// Server-side logging for debugging/observability.
 			log.Printf("machines/register failed user_id=%q machine_id=%q machine_name=%q err=%v", user.ID, req.MachineID, req.MachineName, err)
@@ -140,3 +151,46 @@ func registerMachineHandler(store repo.MachineStore) http.Handler {
 		_, _ = io.WriteString(w, "ok")
 	})
 }
+
+// listMachinesHandler returns the caller's registered machines and their
+// keys. The CLI wraps content keys only to the machines the user approved,
+// checking each encryption key's signature against the pinned device key.
+func listMachinesHandler(store repo.MachineStore) http.Handler {
+	if store == nil {
+		store = repo.DisabledMachineStore{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok || strings.TrimSpace(user.ID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		machines, err := store.ListMachines(r.Context(), user.ID)
+		if err != nil {
+			log.Printf("machines list failed user_id=%s err=%v", user.ID, err)
+			switch err {
+			case repo.ErrDBNotConfigured:
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = io.WriteString(w, "db not configured")
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = io.WriteString(w, "machines failed")
+			}
+			return
+		}
+		if machines == nil {
+			machines = []repo.MachineInfo{}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(machines)
+	})
+}
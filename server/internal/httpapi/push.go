@@ -111,9 +111,12 @@ func pushHandler(store repo.PushStore, idem repo.IdempotencyStore) http.Handler
 			if idemKey != "" {
 				_ = idem.Delete(r.Context(), user.ID, idemScope, idemKey)
 			}
-			switch err {
-			case repo.ErrDBNotConfigured:
+			var mismatch *repo.ParentMismatchError
+			switch {
+			case err == repo.ErrDBNotConfigured:
 				writeHTTPError(w, http.StatusServiceUnavailable, "db not configured", err)
+			case errors.As(err, &mismatch):
+				writePushConflict(w, mismatch)
 			default:
 				writeHTTPError(w, http.StatusInternalServerError, "push failed", err)
 			}
@@ -129,3 +132,21 @@ func pushHandler(store repo.PushStore, idem repo.IdempotencyStore) http.Handler
 		_ = json.NewEncoder(w).Encode(res)
 	})
 }
+
+type pushConflictResponse struct {
+	Error        string `json:"error"`
+	Message      string `json:"message"`
+	HeadClientID string `json:"head_client_id,omitempty"`
+}
+
+// writePushConflict returns a typed 409 so clients can tell a stale parent
+// apart from other conflicts (e.g. a reused idempotency key).
+func writePushConflict(w http.ResponseWriter, mismatch *repo.ParentMismatchError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	_ = json.NewEncoder(w).Encode(pushConflictResponse{
+		Error:        "parent_mismatch",
+		Message:      "commit parent is not the remote head; sync and commit again",
+		HeadClientID: mismatch.HeadClientID,
+	})
+}
@@ -18,6 +18,7 @@ type Deps struct {
 	Files    repo.FileStore
 	Export   repo.ExportStore
 	Push     repo.PushStore
+	Links    repo.LinkStore
 }
 
 func New(deps Deps) http.Handler {
@@ -35,7 +36,11 @@ func New(deps Deps) http.Handler {
 	mux.Handle("/commits", requireLoopback(deps.Auth.Require(commitsHandler(deps.Commits))))
 	mux.Handle("/files", requireLoopback(deps.Auth.Require(filesHandler(deps.Files))))
 	mux.Handle("/export", requireLoopback(deps.Auth.Require(exportHandler(deps.Export))))
+	mux.Handle("/machines", requireLoopback(deps.Auth.Require(listMachinesHandler(deps.Machines))))
 	mux.Handle("/machines/register", requireLoopback(deps.Auth.Require(requireMachineRegisterRateLimit(registerMachineHandler(deps.Machines)))))
+	mux.Handle("/machines/link", requireLoopback(deps.Auth.Require(requireMachineRegisterRateLimit(requireDeviceSignature(deps.Machines, linksHandler(deps.Links))))))
+	mux.Handle("/machines/link/approve", requireLoopback(deps.Auth.Require(requireDeviceSignature(deps.Machines, approveLinkHandler(deps.Links)))))
+	mux.Handle("/machines/link/bundle", requireLoopback(deps.Auth.Require(requireDeviceSignature(deps.Machines, linkBundleHandler(deps.Links)))))
 	mux.Handle("/push", requireLoopback(deps.Auth.Require(requirePushRateLimit(requireDeviceSignature(deps.Machines, pushHandler(deps.Push, deps.Idem))))))
 
 	return mux
@@ -18,6 +18,10 @@ func init() {
   "type": "object",
   "additionalProperties": false,
   "required": ["v", "project", "machine", "commit", "files"],
+  "anyOf": [
+    {"properties": {"files": {"minItems": 1}}},
+    {"required": ["deleted"], "properties": {"deleted": {"minItems": 1}}}
+  ],
   "properties": {
     "v": {"type": "integer", "const": 1},
     "project": {
@@ -52,12 +56,34 @@ func init() {
           "format": "uuid"
         },
         "message": {"type": "string", "minLength": 1, "maxLength": 500},
-        "parent_client_id": {"type": "string", "format": "uuid"}
+        "parent_client_id": {
+          "type": "string",
+          "description": "client_id of the commit this one is based on. Must equal the current remote head, otherwise the push is rejected with 409 parent_mismatch.",
+          "format": "uuid"
+        }
+      }
+    },
+    "deleted": {
+      "type": "array",
+      "description": "Tombstones: paths this commit removes from the project. A commit may carry only deletions.",
+      "maxItems": 200,
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["path"],
+        "properties": {
+          "path": {
+            "type": "string",
+            "minLength": 1,
+            "maxLength": 500,
+            "pattern": "^(?:[A-Za-z0-9._-]+/)*(?:[A-Za-z0-9_-][A-Za-z0-9._-]*)?\\.env(?:\\.[A-Za-z0-9._-]+)*$"
+          }
+        }
       }
     },
     "files": {
       "type": "array",
-      "minItems": 1,
+      "minItems": 0,
       "maxItems": 200,
       "items": {
         "type": "object",
@@ -68,7 +94,7 @@ func init() {
 			"type": "string",
 			"minLength": 1,
 			"maxLength": 500,
-			"pattern": "^(?:[A-Za-z0-9._-]+/)*\\.env(?:\\.[A-Za-z0-9._-]+)*$"
+			"pattern": "^(?:[A-Za-z0-9._-]+/)*(?:[A-Za-z0-9_-][A-Za-z0-9._-]*)?\\.env(?:\\.[A-Za-z0-9._-]+)*$"
 		  },
           "sha256": {
             "type": "string",
@@ -77,7 +103,7 @@ func init() {
           },
           "size": {"type": "integer", "minimum": 1, "maximum": 1048576},
           "encrypted": {"type": "boolean", "const": true},
-		  "cipher": {"type": "string", "enum": ["ed25519+aes-256-gcm-v1", "age-v1", "sentra-v1"]},
+		  "cipher": {"type": "string", "enum": ["ed25519+aes-256-gcm-v1", "age-v1", "sentra-v1", "sentra-v2"]},
 		  "blob": {"type": "string", "minLength": 1, "maxLength": 8000000},
 		  "storage": {
 			"type": "object",
@@ -131,5 +157,43 @@ func validateJSONAgainstPushSchema(body []byte) error {
 	if err := json.Unmarshal(body, &v); err != nil {
 		return err
 	}
-	return pushRequestSchema.Validate(v)
+	if err := pushRequestSchema.Validate(v); err != nil {
+		return err
+	}
+	return validatePushPaths(body)
+}
+
+// validatePushPaths rejects what the schema can't express: a path listed
+// twice, or both written and deleted in the same commit.
+func validatePushPaths(body []byte) error {
+	var req struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+		Deleted []struct {
+			Path string `json:"path"`
+		} `json:"deleted"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return err
+	}
+	seen := make(map[string]struct{}, len(req.Files)+len(req.Deleted))
+	add := func(p string) error {
+		if _, ok := seen[p]; ok {
+			return fmt.Errorf("duplicate path %q", p)
+		}
+		seen[p] = struct{}{}
+		return nil
+	}
+	for _, f := range req.Files {
+		if err := add(f.Path); err != nil {
+			return err
+		}
+	}
+	for _, d := range req.Deleted {
+		if err := add(d.Path); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -16,6 +16,9 @@ type CommitInfo struct {
 	MachineName string   `json:"machine_name"`
 	MachineID   string   `json:"machine_id"`
 	Files       []string `json:"files"`
+	// ClientID is the id the pushing machine gave the commit, when the RPC
+	// returns it. Clients use it to match remote commits to local ones.
+	ClientID string `json:"client_id,omitempty"`
 
 	ProjectID   string `json:"project_id"`
 	ProjectRoot string `json:"project_root"`
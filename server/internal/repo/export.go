@@ -21,6 +21,10 @@ type ExportFile struct {
 	StorageKey      string `json:"storage_key"`
 	StorageEndpoint string `json:"storage_endpoint"`
 	StorageRegion   string `json:"storage_region"`
+	// Deleted marks a tombstone row: the file was removed by CommitID and has
+	// no content. Clients remove or skip it. sentra_export_v3 returns one in
+	// place of any version older than the deletion.
+	Deleted bool `json:"deleted,omitempty"`
 }
 
 type ExportStore interface {
@@ -40,7 +44,7 @@ type SupabaseExportStore struct {
 
 func NewSupabaseExportStore(client *supabase.Client, fn string) SupabaseExportStore {
 	if fn == "" {
-		fn = "sentra_export_v2"
+		fn = "sentra_export_v3"
 	}
 	return SupabaseExportStore{client: client, fn: fn}
 }
@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSupabaseExportStoreReturnsTombstones(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = w.Write([]byte(`[
+			{"commit_id":"c1","file_path":"github.com/org/api/.env","sha256":"ab","size":3,"cipher":"sentra-v2","blob_b64":"eA","deleted":false},
+			{"commit_id":"c2","file_path":"github.com/org/api/.env.local","sha256":"","size":0,"cipher":"","blob_b64":"","deleted":true}
+		]`))
+	})
+
+	files, err := NewSupabaseExportStore(client, "").Export(context.Background(), "user-1", "github.com/org/api", "c2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/rest/v1/rpc/sentra_export_v3" {
+		t.Fatalf("called %s, want sentra_export_v3", gotPath)
+	}
+	if gotBody["p_root"] != "github.com/org/api" || gotBody["p_at"] != "c2" {
+		t.Fatalf("rpc body = %v", gotBody)
+	}
+	if len(files) != 2 || files[0].Deleted || !files[1].Deleted || files[1].CommitID != "c2" {
+		t.Fatalf("files = %+v", files)
+	}
+}
@@ -0,0 +1,317 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mgeovany/sentra/server/internal/supabase"
+)
+
+var ErrLinkNotFound = errors.New("link request not found")
+
+const (
+	LinkPending  = "pending"
+	LinkApproved = "approved"
+)
+
+// LinkRequest is a new machine asking an already-trusted machine for the
+// account's key material. The bundle is sealed to EncPubKey on the approving
+// machine; the server only relays it.
+type LinkRequest struct {
+	MachineID   string `json:"machine_id"`
+	MachineName string `json:"machine_name"`
+	EncPubKey   string `json:"enc_pub_key"`
+	Status      string `json:"status"`
+	ApprovedBy  string `json:"approved_by,omitempty"`
+	Bundle      string `json:"bundle,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+type LinkStore interface {
+	// CreateLink opens (or re-opens) the pending request for req.MachineID.
+	CreateLink(ctx context.Context, userID string, req LinkRequest, ttl time.Duration) error
+	ListPendingLinks(ctx context.Context, userID string) ([]LinkRequest, error)
+	// ApproveLink attaches the sealed bundle. It returns ErrLinkNotFound if no
+	// unexpired pending request exists for machineID.
+	ApproveLink(ctx context.Context, userID, machineID, approvedBy, bundle string) error
+	GetLink(ctx context.Context, userID, machineID string) (LinkRequest, bool, error)
+	DeleteLink(ctx context.Context, userID, machineID string) error
+}
+
+type DisabledLinkStore struct{}
+
+func (DisabledLinkStore) CreateLink(ctx context.Context, userID string, req LinkRequest, ttl time.Duration) error {
+	return ErrDBNotConfigured
+}
+
+func (DisabledLinkStore) ListPendingLinks(ctx context.Context, userID string) ([]LinkRequest, error) {
+	return nil, ErrDBNotConfigured
+}
+
+func (DisabledLinkStore) ApproveLink(ctx context.Context, userID, machineID, approvedBy, bundle string) error {
+	return ErrDBNotConfigured
+}
+
+func (DisabledLinkStore) GetLink(ctx context.Context, userID, machineID string) (LinkRequest, bool, error) {
+	return LinkRequest{}, false, ErrDBNotConfigured
+}
+
+func (DisabledLinkStore) DeleteLink(ctx context.Context, userID, machineID string) error {
+	return ErrDBNotConfigured
+}
+
+type SupabaseLinkStore struct {
+	client *supabase.Client
+	table  string
+}
+
+func NewSupabaseLinkStore(client *supabase.Client, table string) SupabaseLinkStore {
+	if table == "" {
+		table = "machine_links"
+	}
+	return SupabaseLinkStore{client: client, table: table}
+}
+
+func (s SupabaseLinkStore) CreateLink(ctx context.Context, userID string, req LinkRequest, ttl time.Duration) error {
+	if s.client == nil {
+		return ErrDBNotConfigured
+	}
+	userID = strings.TrimSpace(userID)
+	machineID := strings.TrimSpace(req.MachineID)
+	if userID == "" || machineID == "" || strings.TrimSpace(req.EncPubKey) == "" {
+		return fmt.Errorf("invalid link request payload")
+	}
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+
+	u, err := url.Parse(s.client.PostgRESTURL(s.table))
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("on_conflict", "user_id,machine_id")
+	u.RawQuery = q.Encode()
+
+	// Re-running `sentra link` replaces any earlier request (and its bundle).
+	payload := map[string]any{
+		"user_id":      userID,
+		"machine_id":   machineID,
+		"machine_name": strings.TrimSpace(req.MachineName),
+		"enc_pub_key":  strings.TrimSpace(req.EncPubKey),
+		"status":       LinkPending,
+		"approved_by":  nil,
+		"bundle":       nil,
+		"expires_at":   time.Now().UTC().Add(ttl).Format(time.RFC3339),
+	}
+
+	resp, body, err := s.client.PostJSON(ctx, u.String(), payload, map[string]string{
+		"Prefer": "resolution=merge-duplicates,return=minimal",
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("supabase upsert machine_links failed: status=%d body=%s", resp.StatusCode, string(body))
+}
+
+func (s SupabaseLinkStore) ListPendingLinks(ctx context.Context, userID string) ([]LinkRequest, error) {
+	if s.client == nil {
+		return nil, ErrDBNotConfigured
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return nil, fmt.Errorf("invalid link list payload")
+	}
+
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID)
+	q.Set("status", "eq."+LinkPending)
+	q.Set("expires_at", "gt."+time.Now().UTC().Format(time.RFC3339))
+	q.Set("select", "machine_id,machine_name,enc_pub_key,status,created_at,expires_at")
+	q.Set("order", "created_at.asc")
+
+	b, err := s.selectRows(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	var out []LinkRequest
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s SupabaseLinkStore) ApproveLink(ctx context.Context, userID, machineID, approvedBy, bundle string) error {
+	if s.client == nil {
+		return ErrDBNotConfigured
+	}
+	userID = strings.TrimSpace(userID)
+	machineID = strings.TrimSpace(machineID)
+	approvedBy = strings.TrimSpace(approvedBy)
+	bundle = strings.TrimSpace(bundle)
+	if userID == "" || machineID == "" || approvedBy == "" || bundle == "" {
+		return fmt.Errorf("invalid link approve payload")
+	}
+
+	u, err := url.Parse(s.client.PostgRESTURL(s.table))
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("user_id", "eq."+userID)
+	q.Set("machine_id", "eq."+machineID)
+	q.Set("status", "eq."+LinkPending)
+	q.Set("expires_at", "gt."+time.Now().UTC().Format(time.RFC3339))
+	q.Set("select", "machine_id")
+	u.RawQuery = q.Encode()
+
+	b, err := json.Marshal(map[string]any{
+		"status":      LinkApproved,
+		"approved_by": approvedBy,
+		"bundle":      bundle,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, u.String(), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	// Ask for the updated rows so a no-op (expired/unknown request) is detectable.
+	req.Header.Set("Prefer", "return=representation")
+	req.Header.Set("apikey", s.client.APIKey())
+	req.Header.Set("Authorization", "Bearer "+s.client.APIKey())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("supabase patch machine_links failed: status=%d body=%s", resp.StatusCode, string(respBody))
+	}
+
+	var rows []json.RawMessage
+	if err := json.Unmarshal(respBody, &rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+func (s SupabaseLinkStore) GetLink(ctx context.Context, userID, machineID string) (LinkRequest, bool, error) {
+	if s.client == nil {
+		return LinkRequest{}, false, ErrDBNotConfigured
+	}
+	userID = strings.TrimSpace(userID)
+	machineID = strings.TrimSpace(machineID)
+	if userID == "" || machineID == "" {
+		return LinkRequest{}, false, fmt.Errorf("invalid link lookup payload")
+	}
+
+	q := url.Values{}
+	q.Set("user_id", "eq."+userID)
+	q.Set("machine_id", "eq."+machineID)
+	q.Set("select", "machine_id,machine_name,enc_pub_key,status,approved_by,bundle,created_at,expires_at")
+
+	b, err := s.selectRows(ctx, q)
+	if err != nil {
+		return LinkRequest{}, false, err
+	}
+	var out []LinkRequest
+	if err := json.Unmarshal(b, &out); err != nil {
+		return LinkRequest{}, false, err
+	}
+	if len(out) == 0 {
+		return LinkRequest{}, false, nil
+	}
+	return out[0], true, nil
+}
+
+func (s SupabaseLinkStore) DeleteLink(ctx context.Context, userID, machineID string) error {
+	if s.client == nil {
+		return ErrDBNotConfigured
+	}
+	userID = strings.TrimSpace(userID)
+	machineID = strings.TrimSpace(machineID)
+	if userID == "" || machineID == "" {
+		return fmt.Errorf("invalid link delete payload")
+	}
+
+	u, err := url.Parse(s.client.PostgRESTURL(s.table))
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("user_id", "eq."+userID)
+	q.Set("machine_id", "eq."+machineID)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Prefer", "return=minimal")
+	req.Header.Set("apikey", s.client.APIKey())
+	req.Header.Set("Authorization", "Bearer "+s.client.APIKey())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("supabase delete machine_links failed: status=%d body=%s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func (s SupabaseLinkStore) selectRows(ctx context.Context, q url.Values) ([]byte, error) {
+	u, err := url.Parse(s.client.PostgRESTURL(s.table))
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("apikey", s.client.APIKey())
+	req.Header.Set("Authorization", "Bearer "+s.client.APIKey())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("supabase select machine_links failed: status=%d body=%s", resp.StatusCode, string(b))
+	}
+	return b, nil
+}
@@ -18,17 +18,35 @@ var (
 	ErrTooManyMachines = errors.New("too many machines")
 )
 
+type MachineInfo struct {
+	MachineID   string `json:"machine_id"`
+	MachineName string `json:"machine_name"`
+	// DevicePubKey is the machine's Ed25519 device key (base64url).
+	DevicePubKey string `json:"device_pub_key,omitempty"`
+	// EncPubKey is the machine's X25519 public key (base64url). Clients wrap
+	// per-file content keys to it for the machines the user approved.
+	EncPubKey string `json:"enc_pub_key,omitempty"`
+	// EncPubKeySig is DevicePubKey's signature over EncPubKey.
+	EncPubKeySig string `json:"enc_pub_key_sig,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
+
 type MachineStore interface {
-	Register(ctx context.Context, userID, machineID, machineName, devicePubKey string) error
+	Register(ctx context.Context, userID, machineID, machineName, devicePubKey, encPubKey, encPubKeySig string) error
 	DevicePubKey(ctx context.Context, userID, machineID string) (string, bool, error)
+	ListMachines(ctx context.Context, userID string) ([]MachineInfo, error)
 }
 
 type DisabledMachineStore struct{}
 
-func (DisabledMachineStore) Register(ctx context.Context, userID, machineID, machineName, devicePubKey string) error {
+func (DisabledMachineStore) Register(ctx context.Context, userID, machineID, machineName, devicePubKey, encPubKey, encPubKeySig string) error {
 	return ErrDBNotConfigured
 }
 
+func (DisabledMachineStore) ListMachines(ctx context.Context, userID string) ([]MachineInfo, error) {
+	return nil, ErrDBNotConfigured
+}
+
 func (DisabledMachineStore) DevicePubKey(ctx context.Context, userID, machineID string) (string, bool, error) {
 	return "", false, ErrDBNotConfigured
 }
@@ -45,7 +63,7 @@ func NewSupabaseMachineStore(client *supabase.Client, table string) SupabaseMach
 	return SupabaseMachineStore{client: client, table: table}
 }
 
-func (s SupabaseMachineStore) Register(ctx context.Context, userID, machineID, machineName, devicePubKey string) error {
+func (s SupabaseMachineStore) Register(ctx context.Context, userID, machineID, machineName, devicePubKey, encPubKey, encPubKeySig string) error {
 	if s.client == nil {
 		return ErrDBNotConfigured
 	}
@@ -71,6 +89,13 @@ func (s SupabaseMachineStore) Register(ctx context.Context, userID, machineID, m
 		"device_pub_key":  strings.TrimSpace(devicePubKey),
 		"device_key_type": "ed25519",
 	}
+	// Older CLIs don't send an encryption key; don't clear one set by a newer CLI.
+	if v := strings.TrimSpace(encPubKey); v != "" {
+		payload["enc_pub_key"] = v
+	}
+	if v := strings.TrimSpace(encPubKeySig); v != "" {
+		payload["enc_pub_key_sig"] = v
+	}
 
 	headers := map[string]string{
 		"Prefer": "resolution=merge-duplicates,return=minimal",
@@ -159,3 +184,48 @@ func (s SupabaseMachineStore) DevicePubKey(ctx context.Context, userID, machineI
 	}
 	return pk, true, nil
 }
+
+func (s SupabaseMachineStore) ListMachines(ctx context.Context, userID string) ([]MachineInfo, error) {
+	if s.client == nil {
+		return nil, ErrDBNotConfigured
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return nil, fmt.Errorf("invalid machine list payload")
+	}
+
+	// /rest/v1/machines?user_id=eq.<>&select=machine_id,machine_name,device_pub_key,enc_pub_key,enc_pub_key_sig,created_at
+	u, err := url.Parse(s.client.PostgRESTURL(s.table))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("user_id", "eq."+userID)
+	q.Set("select", "machine_id,machine_name,device_pub_key,enc_pub_key,enc_pub_key_sig,created_at")
+	q.Set("order", "created_at.asc")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("apikey", s.client.APIKey())
+	req.Header.Set("Authorization", "Bearer "+s.client.APIKey())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("supabase select machines failed: status=%d body=%s", resp.StatusCode, string(b))
+	}
+
+	var out []MachineInfo
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
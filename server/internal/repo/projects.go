@@ -15,6 +15,9 @@ type ProjectInfo struct {
 	LastCommitID      string `json:"last_commit_id"`
 	LastCommitMessage string `json:"last_commit_message"`
 	FileCount         int    `json:"file_count"`
+	// LastClientID is the client id of the project's remote head, as recorded
+	// by sentra_push_v2. Empty for projects not pushed since heads were kept.
+	LastClientID string `json:"last_client_id,omitempty"`
 }
 
 type ProjectStore interface {
@@ -34,7 +37,7 @@ type SupabaseProjectStore struct {
 
 func NewSupabaseProjectStore(client *supabase.Client, fn string) SupabaseProjectStore {
 	if fn == "" {
-		fn = "sentra_projects_v1"
+		fn = "sentra_projects_v2"
 	}
 	return SupabaseProjectStore{client: client, fn: fn}
 }
@@ -2,6 +2,8 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,6 +11,10 @@ import (
 	"github.com/mgeovany/sentra/server/internal/supabase"
 )
 
+// PushStore records a validated push payload. The payload may carry
+// tombstones (`deleted`) alongside or instead of files; sentra_push_v2
+// (supabase/migrations/*_sentra_tombstones.sql) records them with the commit
+// so export lists those paths as deleted from then on.
 type PushStore interface {
 	Push(ctx context.Context, userID string, payload any) (PushResult, error)
 }
@@ -20,6 +26,28 @@ type PushResult struct {
 	Deduped    bool   `json:"deduped"`
 }
 
+// ErrParentMismatch is returned (wrapped in a *ParentMismatchError) when a push
+// names a parent_client_id that is not the project's current remote head.
+// sentra_push_v2 (supabase/migrations/*_sentra_push_lineage.sql) checks this
+// under a lock on the project's head row, in the same transaction that
+// inserts the commit and moves the head, so two machines cannot both advance
+// the same head.
+var ErrParentMismatch = errors.New("push parent is not the remote head")
+
+type ParentMismatchError struct {
+	// HeadClientID is the client id of the current remote head, when known.
+	HeadClientID string
+}
+
+func (e *ParentMismatchError) Error() string {
+	if e.HeadClientID == "" {
+		return ErrParentMismatch.Error()
+	}
+	return fmt.Sprintf("%s (head=%s)", ErrParentMismatch.Error(), e.HeadClientID)
+}
+
+func (e *ParentMismatchError) Unwrap() error { return ErrParentMismatch }
+
 type DisabledPushStore struct{}
 
 func (DisabledPushStore) Push(ctx context.Context, userID string, payload any) (PushResult, error) {
@@ -33,7 +61,7 @@ type SupabasePushStore struct {
 
 func NewSupabasePushStore(client *supabase.Client, fn string) SupabasePushStore {
 	if fn == "" {
-		fn = "sentra_push_v1"
+		fn = "sentra_push_v2"
 	}
 	return SupabasePushStore{client: client, fn: fn}
 }
@@ -64,6 +92,9 @@ func (s SupabasePushStore) Push(ctx context.Context, userID string, payload any)
 		return PushResult{}, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if mismatch, ok := parentMismatchFromRPC(respBody); ok {
+			return PushResult{}, mismatch
+		}
 		return PushResult{}, fmt.Errorf("supabase rpc push failed: status=%d body=%s", resp.StatusCode, string(respBody))
 	}
 
@@ -78,5 +109,23 @@ func (s SupabasePushStore) Push(ctx context.Context, userID string, payload any)
 	return out[0], nil
 }
 
+// parentMismatchFromRPC maps the push RPC's lineage check to a typed error.
+// sentra_push_v2 raises `parent mismatch` with the current head client id as
+// the detail.
+func parentMismatchFromRPC(body []byte) (*ParentMismatchError, bool) {
+	var apiErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details string `json:"details"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		return nil, false
+	}
+	if !strings.Contains(strings.ToLower(strings.TrimSpace(apiErr.Message)), "parent mismatch") {
+		return nil, false
+	}
+	return &ParentMismatchError{HeadClientID: strings.TrimSpace(apiErr.Details)}, true
+}
+
 // Supabase uses its own json package in this repo; keep this local helper.
 var _ = http.MethodPost
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mgeovany/sentra/server/internal/supabase"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *supabase.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	client, err := supabase.New(srv.URL, "service-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSupabasePushStoreParentMismatch(t *testing.T) {
+	var gotPath string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"code":"P0001","message":"parent mismatch","details":"7d4a5b0e-58a4-4c43-9d1e-0d0b8c1b1c11","hint":"sync and commit again"}`)
+	})
+
+	_, err := NewSupabasePushStore(client, "").Push(context.Background(), "user-1", map[string]any{"v": 1})
+	if gotPath != "/rest/v1/rpc/sentra_push_v2" {
+		t.Fatalf("called %s, want the lineage-checking sentra_push_v2", gotPath)
+	}
+	var mismatch *ParentMismatchError
+	if !errors.As(err, &mismatch) || !errors.Is(err, ErrParentMismatch) {
+		t.Fatalf("err = %v, want *ParentMismatchError", err)
+	}
+	if mismatch.HeadClientID != "7d4a5b0e-58a4-4c43-9d1e-0d0b8c1b1c11" {
+		t.Fatalf("HeadClientID = %q", mismatch.HeadClientID)
+	}
+}
+
+func TestSupabasePushStoreOtherErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"code":"22P02","message":"invalid input syntax for type uuid"}`)
+	})
+
+	_, err := NewSupabasePushStore(client, "").Push(context.Background(), "user-1", map[string]any{"v": 1})
+	if err == nil || errors.Is(err, ErrParentMismatch) {
+		t.Fatalf("err = %v, want a plain RPC error", err)
+	}
+}
+
+func TestSupabasePushStoreForwardsTombstones(t *testing.T) {
+	var got struct {
+		UserID  string `json:"p_user_id"`
+		Payload struct {
+			Deleted []struct {
+				Path string `json:"path"`
+			} `json:"deleted"`
+		} `json:"p_payload"`
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode([]PushResult{{ProjectID: "p1", CommitID: "c2"}})
+	})
+
+	payload := map[string]any{
+		"v":       1,
+		"project": map[string]any{"root": "github.com/org/api"},
+		"files":   []any{},
+		"deleted": []any{map[string]any{"path": "github.com/org/api/.env.local"}},
+	}
+	res, err := NewSupabasePushStore(client, "").Push(context.Background(), "user-1", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CommitID != "c2" {
+		t.Fatalf("result = %+v", res)
+	}
+	if got.UserID != "user-1" || len(got.Payload.Deleted) != 1 || got.Payload.Deleted[0].Path != "github.com/org/api/.env.local" {
+		t.Fatalf("rpc body = %+v", got)
+	}
+}
+
+func TestSupabaseProjectStoreLastClientID(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/v1/rpc/sentra_projects_v2" {
+			t.Errorf("called %s, want sentra_projects_v2", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"root_path": "github.com/org/api", "last_commit_id": "c1", "file_count": 2, "last_client_id": "head-1"},
+			{"root_path": "legacy", "last_commit_id": "c2", "file_count": 1, "last_client_id": nil},
+		})
+	})
+
+	projects, err := NewSupabaseProjectStore(client, "").ListProjects(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 || projects[0].LastClientID != "head-1" || projects[1].LastClientID != "" {
+		t.Fatalf("projects = %+v", projects)
+	}
+}
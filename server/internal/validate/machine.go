@@ -68,3 +68,36 @@ func DevicePubKeyB64(s string) error {
 	}
 	return nil
 }
+
+// EncPubKeyB64 validates an optional X25519 public key (32 bytes, base64url).
+func EncPubKeyB64(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	// 32 bytes -> 43 chars of unpadded base64url.
+	if len(s) != 43 {
+		return errors.New("invalid enc_pub_key length")
+	}
+	if err := DevicePubKeyB64(s); err != nil {
+		return errors.New("invalid enc_pub_key")
+	}
+	return nil
+}
+
+// EncPubKeySigB64 validates an optional Ed25519 signature (64 bytes, base64url)
+// over an enc_pub_key.
+func EncPubKeySigB64(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	// 64 bytes -> 86 chars of unpadded base64url.
+	if len(s) != 86 {
+		return errors.New("invalid enc_pub_key_sig length")
+	}
+	if err := DevicePubKeyB64(s); err != nil {
+		return errors.New("invalid enc_pub_key_sig")
+	}
+	return nil
+}
@@ -38,6 +38,7 @@ func main() {
 	var files repo.FileStore = repo.DisabledFileStore{}
 	var export repo.ExportStore = repo.DisabledExportStore{}
 	var push repo.PushStore = repo.DisabledPushStore{}
+	var links repo.LinkStore = repo.DisabledLinkStore{}
 	if cfg.SupabaseURL != "" && cfg.SupabaseServiceRoleKey != "" {
 		client, err := supabase.New(cfg.SupabaseURL, cfg.SupabaseServiceRoleKey)
 		if err != nil {
@@ -50,11 +51,12 @@ func main() {
 			files = repo.NewSupabaseFileStore(client, "")
 			export = repo.NewSupabaseExportStore(client, "")
 			push = repo.NewSupabasePushStore(client, "")
+			links = repo.NewSupabaseLinkStore(client, "")
 			log.Printf("supabase db configured")
 		}
 	}
 
-	h := httpapi.New(httpapi.Deps{Auth: middleware, Machines: machines, Idem: idem, Projects: projects, Commits: commits, Files: files, Export: export, Push: push})
+	h := httpapi.New(httpapi.Deps{Auth: middleware, Machines: machines, Idem: idem, Projects: projects, Commits: commits, Files: files, Export: export, Push: push, Links: links})
 
 	srv := &http.Server{
 		Addr:              net.JoinHostPort(cfg.Host, cfg.Port),
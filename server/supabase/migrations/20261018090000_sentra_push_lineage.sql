@@ -0,0 +1,88 @@
+-- Push lineage: every push names the commit it was based on
+-- (commit.parent_client_id) and is rejected unless that is the project's
+-- current remote head. The check, the insert and the head update run in one
+-- transaction, under a row lock on the head, so two machines cannot both
+-- advance the same head.
+--
+-- sentra_push_v1 and sentra_projects_v1 are unchanged; the v2 functions wrap
+-- them and are what the server calls.
+
+create table if not exists public.sentra_project_heads (
+  user_id        uuid        not null,
+  root           text        not null,
+  -- client_id of the last commit pushed to the project.
+  head_client_id text        not null,
+  updated_at     timestamptz not null default now(),
+  primary key (user_id, root)
+);
+
+alter table public.sentra_project_heads enable row level security;
+
+create or replace function public.sentra_push_v2(p_user_id uuid, p_payload jsonb)
+returns table (out_project_id uuid, out_commit_id uuid, received_at timestamptz, deduped boolean)
+language plpgsql
+security definer
+set search_path = public
+as $$
+declare
+  v_root      text := coalesce(p_payload->'project'->>'root', p_payload->'project'->>'id');
+  v_client_id text := p_payload->'commit'->>'client_id';
+  v_parent    text := nullif(p_payload->'commit'->>'parent_client_id', '');
+  v_head      text;
+begin
+  if v_root is null or v_client_id is null then
+    raise exception 'invalid push: missing project or commit client_id';
+  end if;
+
+  -- Lock the head row (creating it for a project's first push) until the
+  -- transaction ends. Concurrent pushes to the project wait here.
+  insert into sentra_project_heads (user_id, root, head_client_id)
+  values (p_user_id, v_root, '')
+  on conflict (user_id, root) do nothing;
+
+  select h.head_client_id into v_head
+  from sentra_project_heads h
+  where h.user_id = p_user_id and h.root = v_root
+  for update;
+
+  -- An empty head is a new project, or one last pushed before heads were
+  -- recorded: any parent is accepted. A retry of the head commit itself is
+  -- left to sentra_push_v1, which returns it as deduped.
+  if v_head <> '' and v_client_id <> v_head and v_parent is distinct from v_head then
+    raise exception 'parent mismatch'
+      using detail = v_head,
+            hint = 'sync and commit again';
+  end if;
+
+  -- sentra_push_v1 predates lineage; hand it the payload it was written for.
+  return query
+  select r.out_project_id::uuid, r.out_commit_id::uuid, r.received_at::timestamptz, r.deduped::boolean
+  from sentra_push_v1(p_user_id, p_payload #- '{commit,parent_client_id}') r;
+
+  update sentra_project_heads
+  set head_client_id = v_client_id, updated_at = now()
+  where user_id = p_user_id and root = v_root;
+end;
+$$;
+
+-- sentra_projects_v1 plus the remote head of each project, so clients can
+-- chain their next commit onto it.
+create or replace function public.sentra_projects_v2(p_user_id uuid)
+returns table (root_path text, last_commit_id text, last_commit_message text, file_count integer, last_client_id text)
+language sql
+stable
+security definer
+set search_path = public
+as $$
+  select p.root_path::text,
+         p.last_commit_id::text,
+         p.last_commit_message::text,
+         p.file_count::integer,
+         nullif(h.head_client_id, '')
+  from sentra_projects_v1(p_user_id) p
+  left join sentra_project_heads h
+    on h.user_id = p_user_id and h.root = p.root_path::text;
+$$;
+
+revoke all on function public.sentra_push_v2(uuid, jsonb) from public, anon, authenticated;
+revoke all on function public.sentra_projects_v2(uuid) from public, anon, authenticated;
@@ -0,0 +1,208 @@
+-- Tombstones: a push may carry `deleted` paths alongside or instead of files.
+-- sentra_push_v2 records them with the commit, and sentra_export_v3 reports
+-- a path as deleted (deleted = true, no content) from that commit on, until
+-- a later commit writes it again.
+--
+-- sentra_push_v1 and sentra_export_v2 are unchanged and know nothing of
+-- deletions; commits are ordered here by sentra_push_log.seq. A push of
+-- tombstones only never reaches sentra_push_v1: the commit exists in
+-- sentra_push_log alone (has_files = false), so commit listings built on
+-- sentra_push_v1's tables do not show it.
+
+create table if not exists public.sentra_push_log (
+  seq        bigserial   primary key,
+  user_id    uuid        not null,
+  root       text        not null,
+  client_id  text        not null,
+  commit_id  uuid        not null,
+  project_id uuid,
+  -- false for a push of tombstones only, which sentra_push_v1 never saw.
+  has_files  boolean     not null default true,
+  created_at timestamptz not null default now(),
+  unique (user_id, root, client_id)
+);
+
+create index if not exists sentra_push_log_commit_idx on public.sentra_push_log (user_id, root, commit_id);
+
+create table if not exists public.sentra_tombstones (
+  seq       bigint not null references public.sentra_push_log (seq) on delete cascade,
+  user_id   uuid   not null,
+  root      text   not null,
+  file_path text   not null,
+  primary key (user_id, root, file_path, seq)
+);
+
+alter table public.sentra_push_log enable row level security;
+alter table public.sentra_tombstones enable row level security;
+
+create or replace function public.sentra_push_v2(p_user_id uuid, p_payload jsonb)
+returns table (out_project_id uuid, out_commit_id uuid, received_at timestamptz, deduped boolean)
+language plpgsql
+security definer
+set search_path = public
+as $$
+declare
+  v_root      text := coalesce(p_payload->'project'->>'root', p_payload->'project'->>'id');
+  v_client_id text := p_payload->'commit'->>'client_id';
+  v_parent    text := nullif(p_payload->'commit'->>'parent_client_id', '');
+  v_has_files boolean := jsonb_array_length(coalesce(p_payload->'files', '[]'::jsonb)) > 0;
+  v_head      text;
+  v_result    record;
+  v_seq       bigint;
+begin
+  if v_root is null or v_client_id is null then
+    raise exception 'invalid push: missing project or commit client_id';
+  end if;
+
+  -- Lock the head row (creating it for a project's first push) until the
+  -- transaction ends. Concurrent pushes to the project wait here.
+  insert into sentra_project_heads (user_id, root, head_client_id)
+  values (p_user_id, v_root, '')
+  on conflict (user_id, root) do nothing;
+
+  select h.head_client_id into v_head
+  from sentra_project_heads h
+  where h.user_id = p_user_id and h.root = v_root
+  for update;
+
+  -- An empty head is a new project, or one last pushed before heads were
+  -- recorded: any parent is accepted. A retry of the head commit itself is
+  -- left to sentra_push_v1, which returns it as deduped.
+  if v_head <> '' and v_client_id <> v_head and v_parent is distinct from v_head then
+    raise exception 'parent mismatch'
+      using detail = v_head,
+            hint = 'sync and commit again';
+  end if;
+
+  if v_has_files then
+    -- sentra_push_v1 predates lineage and tombstones; hand it the payload it
+    -- was written for.
+    select r.out_project_id::uuid as out_project_id,
+           r.out_commit_id::uuid as out_commit_id,
+           r.received_at::timestamptz as received_at,
+           r.deduped::boolean as deduped
+    into v_result
+    from sentra_push_v1(p_user_id, (p_payload - 'deleted') #- '{commit,parent_client_id}') r;
+  else
+    -- Tombstones only: there is nothing for sentra_push_v1 to store. A retry
+    -- returns the commit recorded the first time.
+    select l.project_id as out_project_id,
+           l.commit_id as out_commit_id,
+           l.created_at as received_at,
+           true as deduped
+    into v_result
+    from sentra_push_log l
+    where l.user_id = p_user_id and l.root = v_root and l.client_id = v_client_id;
+
+    if not found then
+      select (select l.project_id from sentra_push_log l
+              where l.user_id = p_user_id and l.root = v_root and l.project_id is not null
+              order by l.seq desc limit 1) as out_project_id,
+             gen_random_uuid() as out_commit_id,
+             now() as received_at,
+             false as deduped
+      into v_result;
+    end if;
+  end if;
+
+  insert into sentra_push_log (user_id, root, client_id, commit_id, project_id, has_files)
+  values (p_user_id, v_root, v_client_id, v_result.out_commit_id, v_result.out_project_id, v_has_files)
+  on conflict (user_id, root, client_id) do nothing
+  returning seq into v_seq;
+
+  -- v_seq is null on a retry: its tombstones were recorded the first time.
+  if v_seq is not null then
+    insert into sentra_tombstones (seq, user_id, root, file_path)
+    select distinct v_seq, p_user_id, v_root, d->>'path'
+    from jsonb_array_elements(coalesce(p_payload->'deleted', '[]'::jsonb)) d
+    where coalesce(d->>'path', '') <> '';
+  end if;
+
+  update sentra_project_heads
+  set head_client_id = v_client_id, updated_at = now()
+  where user_id = p_user_id and root = v_root;
+
+  out_project_id := v_result.out_project_id;
+  out_commit_id := v_result.out_commit_id;
+  received_at := v_result.received_at;
+  deduped := v_result.deduped;
+  return next;
+end;
+$$;
+
+-- sentra_export_v2 with deletions applied: a path whose latest tombstone (up
+-- to p_at) is newer than its exported version is returned as deleted instead.
+-- Versions pushed before sentra_push_log existed count as older than any
+-- tombstone. A p_at the log does not know predates all tombstones. At a
+-- tombstones-only commit, which sentra_export_v2 does not know, the files are
+-- those of the latest commit before it that had any.
+create or replace function public.sentra_export_v3(p_user_id uuid, p_root text, p_at text)
+returns table (
+  commit_id text,
+  file_path text,
+  sha256 text,
+  size integer,
+  cipher text,
+  blob_b64 text,
+  storage_provider text,
+  storage_bucket text,
+  storage_key text,
+  storage_endpoint text,
+  storage_region text,
+  deleted boolean
+)
+language sql
+stable
+security definer
+set search_path = public
+as $$
+  with cutoff as (
+    select coalesce(
+      (select l.seq from sentra_push_log l
+       where l.user_id = p_user_id and l.root = p_root
+         and (l.client_id = p_at or l.commit_id::text = p_at)),
+      case when coalesce(p_at, '') = '' then 9223372036854775807 else 0 end
+    ) as seq
+  ),
+  files_at as (
+    select coalesce(
+      (select b.commit_id::text
+       from sentra_push_log a
+       join sentra_push_log b
+         on b.user_id = a.user_id and b.root = a.root and b.seq < a.seq and b.has_files
+       where a.user_id = p_user_id and a.root = p_root and not a.has_files
+         and (a.client_id = p_at or a.commit_id::text = p_at)
+       order by b.seq desc
+       limit 1),
+      p_at
+    ) as at
+  ),
+  files as (
+    select e.*, coalesce(l.seq, 0) as seq
+    from sentra_export_v2(p_user_id, p_root, (select at from files_at)) e
+    left join sentra_push_log l
+      on l.user_id = p_user_id and l.root = p_root and l.commit_id::text = e.commit_id::text
+  ),
+  tombs as (
+    select distinct on (t.file_path) t.file_path, t.seq, l.commit_id
+    from sentra_tombstones t
+    join sentra_push_log l on l.seq = t.seq
+    where t.user_id = p_user_id and t.root = p_root
+      and t.seq <= (select seq from cutoff)
+    order by t.file_path, t.seq desc
+  )
+  select f.commit_id::text, f.file_path::text, f.sha256::text, f.size::integer, f.cipher::text,
+         f.blob_b64::text, f.storage_provider::text, f.storage_bucket::text, f.storage_key::text,
+         f.storage_endpoint::text, f.storage_region::text, false
+  from files f
+  left join tombs t on t.file_path = f.file_path::text
+  where t.seq is null or t.seq < f.seq
+  union all
+  select t.commit_id::text, t.file_path, '', 0, '', '', '', '', '', '', '', true
+  from tombs t
+  left join files f on f.file_path::text = t.file_path
+  where f.seq is null or t.seq > f.seq;
+$$;
+
+revoke all on function public.sentra_push_v2(uuid, jsonb) from public, anon, authenticated;
+revoke all on function public.sentra_export_v3(uuid, text, text) from public, anon, authenticated;
@@ -0,0 +1,5 @@
+-- Each machine signs its encryption key with its device key. Clients pin the
+-- device keys of machines the user approved and wrap content keys only to
+-- encryption keys carrying a valid signature, so the server can't add or
+-- swap recipients.
+alter table public.machines add column if not exists enc_pub_key_sig text;